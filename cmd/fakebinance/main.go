@@ -0,0 +1,47 @@
+// Command fakebinance serves an in-memory binance for offline development, e.g.
+// go run ./cmd/fakebinance -addr :8090 and BINANCE_URL=http://localhost:8090
+package main
+
+import (
+	"flag"
+	"net/http"
+
+	"github.com/zheka156/market_data/internal/common/log"
+	"github.com/zheka156/market_data/internal/integration/binance/binancetest"
+	"go.uber.org/zap"
+)
+
+var seedPrices = []struct {
+	base  string
+	quote string
+	price string
+}{
+	{"BTC", "USDT", "65000.00"},
+	{"ETH", "USDT", "3200.00"},
+	{"BNB", "USDT", "580.00"},
+	{"SOL", "USDT", "150.00"},
+	{"XRP", "USDT", "0.52"},
+	{"ADA", "USDT", "0.45"},
+	{"DOGE", "USDT", "0.12"},
+	{"ETH", "BTC", "0.04923"},
+	{"USDC", "USDT", "1.0001"},
+	{"FDUSD", "USDT", "0.9998"},
+	{"EUR", "USDT", "1.08"},
+}
+
+func main() {
+	addr := flag.String("addr", ":8090", "listen address")
+	flag.Parse()
+
+	logger, _ := log.NewLogger()
+
+	fake := binancetest.New()
+	for _, seed := range seedPrices {
+		fake.SetPrice(seed.base, seed.quote, seed.price)
+	}
+
+	logger.Info("Fake binance is listening", zap.String("addr", *addr))
+	if err := http.ListenAndServe(*addr, fake.Handler()); err != nil {
+		logger.Fatal("Fake binance stopped", zap.Error(err))
+	}
+}
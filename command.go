@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/zheka156/market_data/internal/job"
+	"github.com/zheka156/market_data/internal/utils"
+)
+
+const runJobCommand = "run-job"
+
+// runJob triggers a job once and prints its report, e.g.
+// md_service run-job -symbols BTC,ETH -dry-run hour_price
+func runJob(ctx context.Context, params *job.JobParams, args []string) int {
+	flags := flag.NewFlagSet(runJobCommand, flag.ContinueOnError)
+	symbols := flags.String("symbols", "", "comma separated symbols to process, all if empty")
+	dryRun := flags.Bool("dry-run", false, "fetch and validate prices without writing them")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s [flags] <job>\nJobs: %s\nFlags:\n", runJobCommand, strings.Join(job.JobNames(), ", "))
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	report, err := params.Trigger(ctx, flags.Arg(0), job.RunOptions{
+		Symbols: utils.GetTickersFromUserInput(*symbols),
+		DryRun:  *dryRun,
+	})
+	if report != nil {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "job %s failed: %s\n", flags.Arg(0), err)
+		return 1
+	}
+	return 0
+}
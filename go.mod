@@ -8,6 +8,7 @@ require (
 	github.com/go-resty/resty/v2 v2.15.3
 	github.com/go-telegram/bot v1.12.1
 	github.com/gofiber/fiber/v2 v2.52.5
+	github.com/gorilla/websocket v1.5.3
 	github.com/jmoiron/sqlx v1.4.0
 	github.com/lib/pq v1.10.9
 	go.uber.org/zap v1.27.0
@@ -0,0 +1,57 @@
+package alert
+
+import (
+	"context"
+	"os"
+
+	"github.com/go-telegram/bot"
+	"github.com/zheka156/market_data/internal/config"
+	"go.uber.org/zap"
+)
+
+// Notifier delivers operational alerts to admins.
+// Delivery errors are logged, alerting never fails the caller.
+type Notifier interface {
+	Notify(ctx context.Context, message string)
+}
+
+type logNotifier struct {
+	log *zap.Logger
+}
+
+func (n *logNotifier) Notify(ctx context.Context, message string) {
+	n.log.Warn("Admin alert", zap.String("message", message))
+}
+
+type telegramNotifier struct {
+	*bot.Bot
+	chatIDs []int64
+	log     *zap.Logger
+}
+
+// NewNotifier sends alerts to the configured admin telegram chats,
+// alerts are only logged if there are none
+func NewNotifier(logger *zap.Logger, conf config.Alerts) Notifier {
+	if len(conf.TelegramChatIDs) == 0 {
+		return &logNotifier{logger}
+	}
+	b, err := bot.New(os.Getenv("TG_TKN"), bot.WithSkipGetMe())
+	if err != nil {
+		logger.Error("Failed to create telegram alerts bot", zap.Error(err))
+		return &logNotifier{logger}
+	}
+	return &telegramNotifier{b, conf.TelegramChatIDs, logger}
+}
+
+func (n *telegramNotifier) Notify(ctx context.Context, message string) {
+	n.log.Warn("Admin alert", zap.String("message", message))
+	for _, chatID := range n.chatIDs {
+		_, err := n.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "⚠️ " + message,
+		})
+		if err != nil {
+			n.log.Error("Failed to send admin alert", zap.Int64("chat", chatID), zap.Error(err))
+		}
+	}
+}
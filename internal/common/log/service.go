@@ -79,18 +79,20 @@ func (lrt *LoggingRoundTripper) RoundTrip(req *http.Request) (*http.Response, er
 			return nil, err
 		}
 
+		// error pages are not always json, they are logged as is and left to the caller
+		var loggedBody zap.Field
 		var respBodyMap interface{}
-		err := json.Unmarshal(respBody, &respBodyMap)
-		if err != nil {
-			lrt.Logger.Error("Failed to unmarshal response body", zap.Error(err))
-			return nil, err
+		if err := json.Unmarshal(respBody, &respBodyMap); err != nil {
+			loggedBody = zap.ByteString("Response body", respBody)
+		} else {
+			loggedBody = zap.Any("Response body", respBodyMap)
 		}
 		lrt.Logger.Info("Incoming response",
 			zap.String("Method", req.Method),
 			zap.String("URL", req.URL.String()),
 			zap.Int("Status", resp.StatusCode),
 			zap.Duration("Duration", duration),
-			zap.Any("Response body", respBodyMap),
+			loggedBody,
 		)
 	}
 
@@ -0,0 +1,49 @@
+// Package pacer spaces requests to APIs that limit requests per second
+package pacer
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Pacer struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// New allows perSecond requests a second, evenly spaced
+func New(perSecond int) *Pacer {
+	return Every(time.Second / time.Duration(perSecond))
+}
+
+// Every allows a request per interval, for limits lower than a request a second
+func Every(interval time.Duration) *Pacer {
+	return &Pacer{interval: interval}
+}
+
+// Wait blocks until the next request slot or until ctx is done
+func (p *Pacer) Wait(ctx context.Context) error {
+	p.mu.Lock()
+	now := time.Now()
+	at := p.next
+	if at.Before(now) {
+		at = now
+	}
+	p.next = at.Add(p.interval)
+	p.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
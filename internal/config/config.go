@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
@@ -9,7 +10,15 @@ import (
 type Config struct {
 	HTTPServer HTTPServer `yaml:"http_server"`
 	Binance    Binance    `yaml:"binance"`
+	Coinbase   Coinbase   `yaml:"coinbase"`
+	Kraken     Kraken     `yaml:"kraken"`
+	CoinGecko  CoinGecko  `yaml:"coingecko"`
+	FX         FX         `yaml:"fx"`
 	Repository Repository `yaml:"repository"`
+	Leader     Leader     `yaml:"leader"`
+	Jobs       Jobs       `yaml:"jobs"`
+	Providers  Providers  `yaml:"providers"`
+	Alerts     Alerts     `yaml:"alerts"`
 }
 
 type HTTPServer struct {
@@ -18,7 +27,141 @@ type HTTPServer struct {
 }
 
 type Binance struct {
-	URL string `yaml:"url"`
+	URL    string        `yaml:"url"`
+	Stream BinanceStream `yaml:"stream"`
+	// WeightLimit is the request weight allowed per minute,
+	// requests are paused once WeightThreshold of it is used
+	WeightLimit     int           `yaml:"weight_limit"`
+	WeightThreshold float64       `yaml:"weight_threshold"`
+	MaxRetries      int           `yaml:"max_retries"`
+	MaxRetryWait    time.Duration `yaml:"max_retry_wait"`
+}
+
+type BinanceStream struct {
+	Enabled         bool          `yaml:"enabled"`
+	URL             string        `yaml:"url"`
+	Type            string        `yaml:"type"`
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+}
+
+type Coinbase struct {
+	URL        string `yaml:"url"`
+	MaxRetries int    `yaml:"max_retries"`
+	// RequestsPerSecond keeps requests under the public rate limit
+	RequestsPerSecond int `yaml:"requests_per_second"`
+	// QuoteSubstitutes prices quotes coinbase doesn't list in another one, e.g. {"USDT": "USD"}
+	QuoteSubstitutes map[string]string `yaml:"quote_substitutes"`
+	// Products maps assets listed under another name to product IDs, e.g. {"POL/USDT": "MATIC-USD"}
+	Products map[string]string `yaml:"products"`
+}
+
+type Kraken struct {
+	URL               string `yaml:"url"`
+	MaxRetries        int    `yaml:"max_retries"`
+	RequestsPerSecond int    `yaml:"requests_per_second"`
+	// QuoteSubstitutes prices quotes kraken doesn't pair a coin with in another one, e.g. {"USDT": "USD"}
+	QuoteSubstitutes map[string]string `yaml:"quote_substitutes"`
+	// Aliases maps kraken asset names to our tickers, XBT and XDG are known without configuration
+	Aliases map[string]string `yaml:"aliases"`
+}
+
+type CoinGecko struct {
+	URL             string        `yaml:"url"`
+	MaxRetries      int           `yaml:"max_retries"`
+	RequestInterval time.Duration `yaml:"request_interval"`
+	// QuoteSubstitutes prices quotes coingecko has no currency for in another one, e.g. {"USDT": "USD"}
+	QuoteSubstitutes map[string]string `yaml:"quote_substitutes"`
+}
+
+type FX struct {
+	// Provider is frankfurter (ECB rates with history) or open_er_api (latest rates only, more currencies)
+	Provider   string `yaml:"provider"`
+	URL        string `yaml:"url"`
+	MaxRetries int    `yaml:"max_retries"`
+	// Currencies are the fiat currencies values can be expressed in besides USD
+	Currencies []string `yaml:"currencies"`
+	// Pegged are assets valued at one USD, e.g. USDT
+	Pegged []string `yaml:"pegged"`
+}
+
+type Leader struct {
+	LockKey       int64         `yaml:"lock_key"`
+	RetryInterval time.Duration `yaml:"retry_interval"`
+	RenewInterval time.Duration `yaml:"renew_interval"`
+}
+
+type Jobs struct {
+	HourPrice HourPriceJob `yaml:"hour_price"`
+	Retention RetentionJob `yaml:"retention"`
+	Stock     StockJob     `yaml:"stock"`
+	Catalog   CatalogJob   `yaml:"catalog"`
+	FXRates   FXRatesJob   `yaml:"fx_rates"`
+}
+
+type HourPriceJob struct {
+	Interval    time.Duration `yaml:"interval"`
+	MaxBackfill time.Duration `yaml:"max_backfill"`
+}
+
+type RetentionJob struct {
+	HourlyRetentionDays int `yaml:"hourly_retention_days"`
+}
+
+type StockJob struct {
+	LookbackDays    int           `yaml:"lookback_days"`
+	RequestInterval time.Duration `yaml:"request_interval"`
+}
+
+type FXRatesJob struct {
+	// Offset is the time of day rates are fetched, ECB rates are published around 16:00 CET
+	Offset time.Duration `yaml:"offset"`
+}
+
+type CatalogJob struct {
+	// QuoteAssets in order of preference, the first traded one is used for a coin
+	QuoteAssets []string `yaml:"quote_assets"`
+	// Allowlist coins are visible, other new coins stay hidden until allowlisted. Denylist coins are never visible
+	Allowlist []string `yaml:"allowlist"`
+	Denylist  []string `yaml:"denylist"`
+	// QuoteOverrides pins the quote asset of a coin, e.g. {"XYZ": "BTC"}
+	QuoteOverrides map[string]string `yaml:"quote_overrides"`
+}
+
+type Providers struct {
+	// Classes lists providers of an asset class in order of preference, the next ones are fallbacks
+	Classes map[string][]string `yaml:"classes"`
+	// Symbols overrides providers of a coin or ticker
+	Symbols map[string][]string `yaml:"symbols"`
+	// Consensus prices hourly quotes from every provider of an asset instead of the first that answers
+	Consensus Consensus `yaml:"consensus"`
+	// CircuitBreaker stops calling a provider that fails too often, its assets go to the fallbacks
+	CircuitBreaker CircuitBreaker `yaml:"circuit_breaker"`
+}
+
+type Consensus struct {
+	Enabled bool `yaml:"enabled"`
+	// Method is median or volume_weighted, volume weighting falls back to the median
+	// if a contributing provider doesn't report volume
+	Method string `yaml:"method"`
+	// MaxDeviation rejects quotes further from the median than the fraction, e.g. 0.02 is 2%
+	MaxDeviation float64 `yaml:"max_deviation"`
+}
+
+type CircuitBreaker struct {
+	// Window is the number of recent calls of a provider the error rate is computed over
+	Window int `yaml:"window"`
+	// MinCalls is the number of calls in the window needed before the circuit may open
+	MinCalls int `yaml:"min_calls"`
+	// MaxErrorRate opens the circuit when the share of failed calls reaches it, e.g. 0.5 is half of them
+	MaxErrorRate float64 `yaml:"max_error_rate"`
+	// SlowCall counts calls taking longer as failed
+	SlowCall time.Duration `yaml:"slow_call"`
+	// OpenFor is how long calls are short-circuited before a probe call is let through
+	OpenFor time.Duration `yaml:"open_for"`
+}
+
+type Alerts struct {
+	TelegramChatIDs []int64 `yaml:"telegram_chat_ids"`
 }
 
 type Repository struct {
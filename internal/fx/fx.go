@@ -0,0 +1,240 @@
+// Package fx expresses USD values in fiat currencies with daily rates
+// fetched from a configurable source and stored in the repository
+package fx
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/config"
+	"github.com/zheka156/market_data/internal/postgres"
+	"go.uber.org/zap"
+)
+
+// USD is the currency rates are based on
+const USD = "USD"
+
+const (
+	day = 24 * time.Hour
+	// rates aren't published on weekends and holidays, the last published one is used meanwhile
+	maxRateAge = 7 * day
+)
+
+var (
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	ErrNoRate              = errors.New("no rate")
+	// ErrHistoryUnsupported is returned by sources that serve the latest rates only
+	ErrHistoryUnsupported = errors.New("historical rates are not supported by source")
+)
+
+// Rates are amounts of each currency per one USD on Date
+type Rates struct {
+	Date  time.Time
+	Rates map[string]decimal.Decimal
+}
+
+type Source interface {
+	Name() string
+	// Rates returns rates of the date, a zero date means the latest rates.
+	// The date of the result is the publication day which may precede the requested one.
+	Rates(date time.Time) (*Rates, error)
+}
+
+// SeriesSource serves rates of a date range in one request
+type SeriesSource interface {
+	Series(from time.Time, to time.Time) ([]*Rates, error)
+}
+
+type Store interface {
+	InsertFXRates(rates []*postgres.FXRate) error
+	GetFXRate(currency string, date time.Time) (*postgres.FXRate, error)
+}
+
+type rateKey struct {
+	currency string
+	date     time.Time
+}
+
+// Service converts USD amounts, pegged stablecoins are treated as USD
+type Service struct {
+	log        *zap.Logger
+	source     Source
+	store      Store
+	currencies []string
+	pegged     map[string]bool
+
+	mu    sync.Mutex
+	cache map[rateKey]decimal.Decimal
+}
+
+func NewService(logger *zap.Logger, source Source, store Store, conf config.FX) *Service {
+	s := &Service{
+		log:    logger,
+		source: source,
+		store:  store,
+		pegged: make(map[string]bool, len(conf.Pegged)),
+		cache:  make(map[rateKey]decimal.Decimal),
+	}
+	for _, currency := range conf.Currencies {
+		currency = strings.ToUpper(currency)
+		if currency != USD && !slices.Contains(s.currencies, currency) {
+			s.currencies = append(s.currencies, currency)
+		}
+	}
+	for _, asset := range conf.Pegged {
+		s.pegged[strings.ToUpper(asset)] = true
+	}
+	return s
+}
+
+// Currencies returns the currencies values can be expressed in, USD included
+func (s *Service) Currencies() []string {
+	return append([]string{USD}, s.currencies...)
+}
+
+// Supports reports whether values can be expressed in the currency
+func (s *Service) Supports(currency string) bool {
+	currency = strings.ToUpper(currency)
+	return currency == USD || slices.Contains(s.currencies, currency)
+}
+
+// IsUSD reports whether the asset is USD or pegged to it
+func (s *Service) IsUSD(asset string) bool {
+	asset = strings.ToUpper(asset)
+	return asset == USD || s.pegged[asset]
+}
+
+// Rate returns the amount of currency per one USD at the time.
+// Stored rates are used first, missing ones are fetched from the source and stored.
+func (s *Service) Rate(currency string, at time.Time) (decimal.Decimal, error) {
+	currency = strings.ToUpper(currency)
+	if s.IsUSD(currency) {
+		return decimal.NewFromInt(1), nil
+	}
+	if !s.Supports(currency) {
+		return decimal.Zero, fmt.Errorf("%s: %w", currency, ErrUnsupportedCurrency)
+	}
+	date := at.UTC().Truncate(day)
+	key := rateKey{currency, date}
+
+	s.mu.Lock()
+	rate, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok {
+		return rate, nil
+	}
+
+	rate, err := s.storedRate(currency, date)
+	if errors.Is(err, ErrNoRate) {
+		if _, err = s.fetch(date); err == nil {
+			rate, err = s.storedRate(currency, date)
+		}
+	}
+	if err != nil {
+		return decimal.Zero, err
+	}
+	// the rate of today may still be replaced by a newer publication
+	if date.Before(time.Now().UTC().Truncate(day)) {
+		s.mu.Lock()
+		s.cache[key] = rate
+		s.mu.Unlock()
+	}
+	return rate, nil
+}
+
+func (s *Service) storedRate(currency string, date time.Time) (decimal.Decimal, error) {
+	stored, err := s.store.GetFXRate(currency, date)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if stored == nil || date.Sub(stored.Date) > maxRateAge {
+		return decimal.Zero, fmt.Errorf("%s on %s: %w", currency, date.Format(time.DateOnly), ErrNoRate)
+	}
+	return stored.Rate, nil
+}
+
+// Convert expresses an amount in USD or a pegged asset in the currency at the rate of the time
+func (s *Service) Convert(amount decimal.Decimal, currency string, at time.Time) (decimal.Decimal, error) {
+	rate, err := s.Rate(currency, at)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return amount.Mul(rate), nil
+}
+
+// Update fetches and stores the latest rates, it returns the number of stored rates
+func (s *Service) Update() (int, error) {
+	return s.fetch(time.Time{})
+}
+
+// Preload stores missing rates of the range in one request if the source serves series,
+// so converting a long history doesn't request every day separately
+func (s *Service) Preload(from time.Time, to time.Time) error {
+	series, ok := s.source.(SeriesSource)
+	if !ok || len(s.currencies) == 0 {
+		return nil
+	}
+	from, to = from.UTC().Truncate(day), to.UTC().Truncate(day)
+	if _, err := s.storedRate(s.currencies[0], from); err == nil {
+		if _, err := s.storedRate(s.currencies[0], to); err == nil {
+			return nil
+		}
+	}
+	// the rate in effect on from may be published a few days before
+	fetched, err := series.Series(from.Add(-maxRateAge), to)
+	if err != nil {
+		return fmt.Errorf("failed to get %s rates series: %w", s.source.Name(), err)
+	}
+	var rows []*postgres.FXRate
+	for _, rates := range fetched {
+		rows = append(rows, s.rows(rates)...)
+	}
+	return s.store.InsertFXRates(rows)
+}
+
+// fetch stores the rates of the date in effect, a zero date means the latest rates
+func (s *Service) fetch(date time.Time) (int, error) {
+	if !date.IsZero() && !date.Before(time.Now().UTC().Truncate(day)) {
+		date = time.Time{}
+	}
+	rates, err := s.source.Rates(date)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get %s rates: %w", s.source.Name(), err)
+	}
+	rows := s.rows(rates)
+	if len(rows) != len(s.currencies) {
+		s.log.Warn("Source has no rates for some currencies", zap.String("source", s.source.Name()),
+			zap.Strings("currencies", s.currencies), zap.Int("rates", len(rows)))
+	}
+	if len(rows) == 0 {
+		return 0, fmt.Errorf("%s returned none of the currencies: %w", s.source.Name(), ErrNoRate)
+	}
+	if err := s.store.InsertFXRates(rows); err != nil {
+		return 0, err
+	}
+	s.log.Info("FX rates stored", zap.String("source", s.source.Name()), zap.Time("date", rates.Date), zap.Int("count", len(rows)))
+	return len(rows), nil
+}
+
+// rows keeps rates of the configured currencies, other currencies of the source are not stored
+func (s *Service) rows(rates *Rates) []*postgres.FXRate {
+	rows := make([]*postgres.FXRate, 0, len(s.currencies))
+	for _, currency := range s.currencies {
+		rate, ok := rates.Rates[currency]
+		if !ok || !rate.IsPositive() {
+			continue
+		}
+		rows = append(rows, &postgres.FXRate{
+			Currency: currency,
+			Date:     rates.Date.UTC().Truncate(day),
+			Rate:     rate,
+			Source:   s.source.Name(),
+		})
+	}
+	return rows
+}
@@ -0,0 +1,97 @@
+package fx
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/config"
+	"github.com/zheka156/market_data/internal/postgres"
+	"go.uber.org/zap"
+)
+
+// fakeSource publishes EUR at 0.9 on the requested date, latest rates are published today
+type fakeSource struct {
+	latestOnly bool
+	calls      int
+}
+
+func (s *fakeSource) Name() string {
+	return "fake"
+}
+
+func (s *fakeSource) Rates(date time.Time) (*Rates, error) {
+	s.calls++
+	if date.IsZero() {
+		date = time.Now().UTC()
+	} else if s.latestOnly {
+		return nil, ErrHistoryUnsupported
+	}
+	return &Rates{Date: date, Rates: map[string]decimal.Decimal{"EUR": decimal.RequireFromString("0.9")}}, nil
+}
+
+type memoryStore struct {
+	rates []*postgres.FXRate
+}
+
+func (s *memoryStore) InsertFXRates(rates []*postgres.FXRate) error {
+	s.rates = append(s.rates, rates...)
+	return nil
+}
+
+func (s *memoryStore) GetFXRate(currency string, date time.Time) (*postgres.FXRate, error) {
+	var found *postgres.FXRate
+	for _, rate := range s.rates {
+		if rate.Currency == currency && !rate.Date.After(date) && (found == nil || rate.Date.After(found.Date)) {
+			found = rate
+		}
+	}
+	return found, nil
+}
+
+func newTestService(source Source, store Store) *Service {
+	return NewService(zap.NewNop(), source, store, config.FX{Currencies: []string{"eur", "GBP"}, Pegged: []string{"USDT"}})
+}
+
+func TestConvertUsesStoredRateOfPastDays(t *testing.T) {
+	friday := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	source := &fakeSource{}
+	store := &memoryStore{rates: []*postgres.FXRate{{Currency: "EUR", Date: friday, Rate: decimal.RequireFromString("0.8")}}}
+	s := newTestService(source, store)
+
+	// the friday rate is in effect over the weekend
+	amount, err := s.Convert(decimal.NewFromInt(100), "eur", friday.Add(50*time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !amount.Equal(decimal.NewFromInt(80)) || source.calls != 0 {
+		t.Errorf("expected 80 EUR from the stored rate, got %s with %d source calls", amount, source.calls)
+	}
+
+	// a day without a stored rate is fetched and stored
+	amount, err = s.Convert(decimal.NewFromInt(100), "EUR", friday.AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !amount.Equal(decimal.NewFromInt(90)) || source.calls != 1 || len(store.rates) != 2 {
+		t.Errorf("expected 90 EUR from the source, got %s with %d source calls", amount, source.calls)
+	}
+}
+
+func TestRateOfPeggedAndUnsupportedCurrencies(t *testing.T) {
+	s := newTestService(&fakeSource{latestOnly: true}, &memoryStore{})
+
+	if rate, err := s.Rate("USDT", time.Now()); err != nil || !rate.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("expected USDT at one USD, got %s (%v)", rate, err)
+	}
+	if _, err := s.Rate("RUB", time.Now()); !errors.Is(err, ErrUnsupportedCurrency) {
+		t.Errorf("expected unsupported currency error, got %v", err)
+	}
+	if _, err := s.Rate("EUR", time.Now().AddDate(-1, 0, 0)); !errors.Is(err, ErrHistoryUnsupported) {
+		t.Errorf("expected history unsupported error, got %v", err)
+	}
+	if _, err := s.Rate("GBP", time.Now()); !errors.Is(err, ErrNoRate) {
+		t.Errorf("expected no rate for a currency the source doesn't publish, got %v", err)
+	}
+}
@@ -0,0 +1,389 @@
+// Package binancetest provides an in-memory fake of the binance spot REST API
+// for tests and offline development. Point the client at it with BINANCE_URL.
+package binancetest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/integration/binance"
+)
+
+const (
+	codeTooManyRequests = -1003
+	codeInvalidSymbol   = -1121
+
+	defaultKlinesLimit = 500
+	maxKlinesLimit     = 1000
+	usedWeightHeader   = "X-MBX-USED-WEIGHT-1M"
+)
+
+var klineIntervals = map[string]time.Duration{
+	"1s":  time.Second,
+	"1m":  time.Minute,
+	"3m":  3 * time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"30m": 30 * time.Minute,
+	"1h":  time.Hour,
+	"2h":  2 * time.Hour,
+	"4h":  4 * time.Hour,
+	"6h":  6 * time.Hour,
+	"8h":  8 * time.Hour,
+	"12h": 12 * time.Hour,
+	"1d":  24 * time.Hour,
+	"3d":  3 * 24 * time.Hour,
+	"1w":  7 * 24 * time.Hour,
+}
+
+type market struct {
+	base   string
+	quote  string
+	price  decimal.Decimal
+	klines []binance.Kline
+	stats  *binance.TickerStats
+}
+
+type failure struct {
+	status     int
+	code       int
+	message    string
+	retryAfter time.Duration
+}
+
+// Server is a fake binance. Symbols that were not set are rejected as invalid like binance does.
+type Server struct {
+	mu         sync.Mutex
+	markets    map[string]*market
+	failures   []failure
+	usedWeight int
+	requests   map[string]int
+}
+
+func New() *Server {
+	return &Server{
+		markets:  make(map[string]*market),
+		requests: make(map[string]int),
+	}
+}
+
+// NewServer starts a fake binance on a local port, close it when the test is done
+func NewServer() (*Server, *httptest.Server) {
+	s := New()
+	return s, httptest.NewServer(s.Handler())
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/ticker/price", s.handle(s.tickerPrice))
+	mux.HandleFunc("GET /api/v3/ticker/24hr", s.handle(s.ticker24hr))
+	mux.HandleFunc("GET /api/v3/klines", s.handle(s.klines))
+	mux.HandleFunc("GET /api/v3/exchangeInfo", s.handle(s.exchangeInfo))
+	mux.HandleFunc("GET /api/v3/ping", s.handle(func(r *http.Request) (int, any) {
+		return http.StatusOK, struct{}{}
+	}))
+	return mux
+}
+
+// SetPrice lists the pair with the given last price
+func (s *Server) SetPrice(base string, quote string, price string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.market(base, quote).price = decimal.RequireFromString(price)
+}
+
+// SetKlines replaces the candles of the pair, they are generated from the last price if not set
+func (s *Server) SetKlines(base string, quote string, klines []binance.Kline) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.market(base, quote).klines = klines
+}
+
+// SetStats replaces the 24 hour stats of the pair, they are generated from the last price if not set
+func (s *Server) SetStats(base string, quote string, stats binance.TickerStats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats.Symbol = binance.Symbol(base, quote)
+	s.market(base, quote).stats = &stats
+}
+
+// Delist removes the pair, requests with it fail as invalid symbol
+func (s *Server) Delist(base string, quote string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.markets, binance.Symbol(base, quote))
+}
+
+// RateLimit answers the next n requests with 429 and the Retry-After header
+func (s *Server) RateLimit(n int, retryAfter time.Duration) {
+	s.fail(n, failure{http.StatusTooManyRequests, codeTooManyRequests, "Too many requests; current limit of IP is 6000 request weight per 1 MINUTE.", retryAfter})
+}
+
+// Ban answers the next n requests with 418 and the Retry-After header
+func (s *Server) Ban(n int, retryAfter time.Duration) {
+	s.fail(n, failure{http.StatusTeapot, codeTooManyRequests, "Way too many requests; IP banned.", retryAfter})
+}
+
+// FailServer answers the next n requests with 500
+func (s *Server) FailServer(n int) {
+	s.fail(n, failure{http.StatusInternalServerError, -1000, "An unknown error occurred while processing the request.", 0})
+}
+
+// SetUsedWeight sets the weight reported in the X-MBX-USED-WEIGHT-1M header
+func (s *Server) SetUsedWeight(weight int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.usedWeight = weight
+}
+
+// Requests returns the number of requests received for the path, e.g. /api/v3/ticker/price
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+func (s *Server) fail(n int, f failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.failures = append(s.failures, f)
+	}
+}
+
+func (s *Server) market(base string, quote string) *market {
+	symbol := binance.Symbol(base, quote)
+	m, ok := s.markets[symbol]
+	if !ok {
+		m = &market{base: base, quote: quote}
+		s.markets[symbol] = m
+	}
+	return m
+}
+
+// handle counts the request, applies queued failures and serves the response under the lock
+func (s *Server) handle(serve func(r *http.Request) (int, any)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(usedWeightHeader, strconv.Itoa(s.usedWeight))
+
+		var status int
+		var body any
+		if len(s.failures) != 0 {
+			f := s.failures[0]
+			s.failures = s.failures[1:]
+			if f.retryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(f.retryAfter.Seconds())))
+			}
+			status, body = f.status, apiError(f.code, f.message)
+		} else {
+			status, body = serve(r)
+		}
+		s.mu.Unlock()
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}
+}
+
+func apiError(code int, message string) map[string]any {
+	return map[string]any{"code": code, "msg": message}
+}
+
+func invalidSymbol() (int, any) {
+	return http.StatusBadRequest, apiError(codeInvalidSymbol, "Invalid symbol.")
+}
+
+// requestedMarkets resolves the symbol or symbols parameter, all markets if none is sent
+func (s *Server) requestedMarkets(r *http.Request) ([]*market, bool) {
+	var symbols []string
+	query := r.URL.Query()
+	switch {
+	case query.Get("symbol") != "":
+		symbols = []string{query.Get("symbol")}
+	case query.Get("symbols") != "":
+		if err := json.Unmarshal([]byte(query.Get("symbols")), &symbols); err != nil {
+			return nil, false
+		}
+	default:
+		for symbol := range s.markets {
+			symbols = append(symbols, symbol)
+		}
+		sort.Strings(symbols)
+	}
+
+	markets := make([]*market, 0, len(symbols))
+	for _, symbol := range symbols {
+		m, ok := s.markets[symbol]
+		if !ok {
+			return nil, false
+		}
+		markets = append(markets, m)
+	}
+	return markets, true
+}
+
+func (s *Server) tickerPrice(r *http.Request) (int, any) {
+	markets, ok := s.requestedMarkets(r)
+	if !ok {
+		return invalidSymbol()
+	}
+	prices := make([]map[string]string, 0, len(markets))
+	for _, m := range markets {
+		prices = append(prices, map[string]string{
+			"symbol": binance.Symbol(m.base, m.quote),
+			"price":  m.price.StringFixed(8),
+		})
+	}
+	if r.URL.Query().Get("symbol") != "" {
+		return http.StatusOK, prices[0]
+	}
+	return http.StatusOK, prices
+}
+
+func (s *Server) ticker24hr(r *http.Request) (int, any) {
+	markets, ok := s.requestedMarkets(r)
+	if !ok {
+		return invalidSymbol()
+	}
+	now := time.Now().UTC()
+	response := make([]map[string]any, 0, len(markets))
+	for _, m := range markets {
+		stats := binance.TickerStats{
+			Symbol:    binance.Symbol(m.base, m.quote),
+			LastPrice: m.price,
+			HighPrice: m.price,
+			LowPrice:  m.price,
+			OpenTime:  now.Add(-24 * time.Hour),
+			CloseTime: now,
+		}
+		if m.stats != nil {
+			stats = *m.stats
+		}
+		response = append(response, map[string]any{
+			"symbol":             stats.Symbol,
+			"priceChange":        stats.PriceChange.StringFixed(8),
+			"priceChangePercent": stats.PriceChangePercent.StringFixed(3),
+			"lastPrice":          stats.LastPrice.StringFixed(8),
+			"highPrice":          stats.HighPrice.StringFixed(8),
+			"lowPrice":           stats.LowPrice.StringFixed(8),
+			"volume":             stats.Volume.StringFixed(8),
+			"quoteVolume":        stats.QuoteVolume.StringFixed(8),
+			"openTime":           stats.OpenTime.UnixMilli(),
+			"closeTime":          stats.CloseTime.UnixMilli(),
+		})
+	}
+	if r.URL.Query().Get("symbol") != "" {
+		return http.StatusOK, response[0]
+	}
+	return http.StatusOK, response
+}
+
+func (s *Server) klines(r *http.Request) (int, any) {
+	query := r.URL.Query()
+	m, ok := s.markets[query.Get("symbol")]
+	if !ok {
+		return invalidSymbol()
+	}
+	interval, ok := klineIntervals[query.Get("interval")]
+	if !ok {
+		return http.StatusBadRequest, apiError(-1120, "Invalid interval.")
+	}
+	limit := defaultKlinesLimit
+	if raw := query.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			return http.StatusBadRequest, apiError(-1100, "Illegal characters found in parameter 'limit'.")
+		}
+		limit = min(parsed, maxKlinesLimit)
+	}
+	start, end := timeParam(query.Get("startTime")), timeParam(query.Get("endTime"))
+
+	klines := m.klines
+	if klines == nil {
+		klines = flatKlines(m.price, interval, start, end, limit)
+	}
+	var window []binance.Kline
+	for _, k := range klines {
+		if !start.IsZero() && k.OpenTime.Before(start) || !end.IsZero() && k.OpenTime.After(end) {
+			continue
+		}
+		window = append(window, k)
+	}
+	// like binance, the earliest klines from startTime, otherwise the latest ones up to endTime
+	if len(window) > limit {
+		if start.IsZero() {
+			window = window[len(window)-limit:]
+		} else {
+			window = window[:limit]
+		}
+	}
+	response := make([][]any, 0, len(window))
+	for _, k := range window {
+		response = append(response, []any{
+			k.OpenTime.UnixMilli(), k.Open.String(), k.High.String(), k.Low.String(), k.Close.String(),
+			k.Volume.String(), k.CloseTime.UnixMilli(), k.QuoteVolume.String(), k.Trades,
+			"0", "0", "0",
+		})
+	}
+	return http.StatusOK, response
+}
+
+// flatKlines generates candles at the last price, the latest ones if start is not set
+func flatKlines(price decimal.Decimal, interval time.Duration, start time.Time, end time.Time, limit int) []binance.Kline {
+	if end.IsZero() {
+		end = time.Now().UTC()
+	}
+	if start.IsZero() {
+		start = end.Add(-time.Duration(limit-1) * interval)
+	}
+	var klines []binance.Kline
+	for open := start.Truncate(interval); !open.After(end) && len(klines) < limit; open = open.Add(interval) {
+		if open.Before(start) {
+			continue
+		}
+		klines = append(klines, binance.Kline{
+			OpenTime:  open,
+			Open:      price,
+			High:      price,
+			Low:       price,
+			Close:     price,
+			CloseTime: open.Add(interval - time.Millisecond),
+		})
+	}
+	return klines
+}
+
+func timeParam(raw string) time.Time {
+	ms, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms).UTC()
+}
+
+func (s *Server) exchangeInfo(r *http.Request) (int, any) {
+	symbols := make([]binance.SymbolInfo, 0, len(s.markets))
+	for symbol, m := range s.markets {
+		symbols = append(symbols, binance.SymbolInfo{
+			Symbol:     symbol,
+			Status:     binance.SymbolStatusTrading,
+			BaseAsset:  m.base,
+			QuoteAsset: m.quote,
+			Filters: []binance.SymbolFilter{
+				{FilterType: "PRICE_FILTER", TickSize: "0.01000000"},
+				{FilterType: "LOT_SIZE", StepSize: "0.00001000"},
+			},
+		})
+	}
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i].Symbol < symbols[j].Symbol })
+	return http.StatusOK, binance.ExchangeInfo{Symbols: symbols}
+}
@@ -1,11 +1,9 @@
 package binance
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
-	"strconv"
 
 	"time"
 
@@ -16,8 +14,12 @@ import (
 )
 
 type Binance interface {
-	GetLastPrice(ticker string) (string, error)
+	GetLastPrice(base string, quote string) (string, error)
 	GetBatchOfLastPrice(tickers string) ([]Pair, error)
+	GetBatchOf24hrStats(tickers string) ([]TickerStats, error)
+	GetKlines(params KlineParams) ([]Kline, error)
+	GetExchangeInfo() (*ExchangeInfo, error)
+	Ping() error
 }
 
 type Client struct {
@@ -36,20 +38,7 @@ func NewClient(logger *zap.Logger, conf *config.Config) *Client {
 	c.SetHeader("Content-Type", "application/json")
 	c.SetBaseURL(os.Getenv("BINANCE_URL"))
 
-	c.OnAfterResponse(func(client *resty.Client, response *resty.Response) error {
-		if response.StatusCode() == http.StatusTooManyRequests {
-			err := getSleepTimeAndWait(logger, response)
-			logger.Sugar().Info("Retrying request")
-			if err != nil {
-				return err
-			}
-			client.R().
-				SetContext(response.Request.Context()).
-				SetBody(response.Request.Body).
-				Execute(response.Request.Method, response.Request.URL)
-		}
-		return nil
-	})
+	setRetryPolicy(c, logger, conf.Binance, newLimiter(logger, conf.Binance))
 
 	return &Client{c, logger}
 }
@@ -59,6 +48,8 @@ type GetLastBatchPriceResponse []Pair
 type Pair struct {
 	Symbol string `json:"symbol"`
 	Price  string `json:"price"`
+	// Time is the binance server time of the response
+	Time time.Time `json:"-"`
 }
 
 func (c *Client) GetBatchOfLastPrice(tickers string) ([]Pair, error) {
@@ -71,17 +62,21 @@ func (c *Client) GetBatchOfLastPrice(tickers string) ([]Pair, error) {
 		c.logger.Error("Failed to get last price", zap.Error(err))
 		return nil, err
 	}
-	err = json.Unmarshal(resp.Body(), &response)
+	err = decodeResponse(resp, &response)
 	if err != nil {
-		c.logger.Error("Failed to unmarshal response", zap.Error(err))
+		c.logger.Error("Failed to decode response", zap.Error(err))
 		return nil, err
 	}
+	serverTime := getServerTime(resp)
+	for i := range response {
+		response[i].Time = serverTime
+	}
 	return response, nil
 }
 
-func (c *Client) GetLastPrice(ticker string) (string, error) {
+func (c *Client) GetLastPrice(base string, quote string) (string, error) {
 	var response Pair
-	param := fmt.Sprintf("%sUSDT", ticker)
+	param := Symbol(base, quote)
 	resp, err := c.R().
 		SetQueryParam("symbol", param).
 		Get("/api/v3/ticker/price")
@@ -89,23 +84,36 @@ func (c *Client) GetLastPrice(ticker string) (string, error) {
 		c.logger.Error("Failed to get last price", zap.Error(err))
 		return "", err
 	}
-	err = json.Unmarshal(resp.Body(), &response)
+	err = decodeResponse(resp, &response)
 	if err != nil {
-		c.logger.Error("Failed to unmarshal response", zap.Error(err))
+		c.logger.Error("Failed to decode response", zap.Error(err))
 		return "", err
 	}
+	if response.Price == "" {
+		return "", fmt.Errorf("no price returned for %s", param)
+	}
 	return response.Price, nil
 }
 
-func getSleepTimeAndWait(logger *zap.Logger, response *resty.Response) error {
-	sleepTimeString := response.Header().Get("Retry-After")
-	logger.Sugar().Warnf("Received signal from binance to sleep for %s seconds", sleepTimeString)
-
-	sleepTime, err := strconv.Atoi(sleepTimeString)
+func (c *Client) Ping() error {
+	resp, err := c.R().Get("/api/v3/ping")
 	if err != nil {
 		return err
 	}
-	logger.Sugar().Warnf("Too many requests made to binance, sleep for %d", sleepTime)
-	time.Sleep(time.Duration(sleepTime) * time.Second)
-	return nil
+	var response struct{}
+	return decodeResponse(resp, &response)
+}
+
+// Symbol returns the binance pair name, e.g. BTCUSDT
+func Symbol(base string, quote string) string {
+	return base + quote
+}
+
+// getServerTime reads the Date header of the response, local time is used if it is missing
+func getServerTime(response *resty.Response) time.Time {
+	serverTime, err := http.ParseTime(response.Header().Get("Date"))
+	if err != nil {
+		return time.Now().UTC()
+	}
+	return serverTime.UTC()
 }
@@ -0,0 +1,245 @@
+package binance_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/config"
+	"github.com/zheka156/market_data/internal/integration/binance"
+	"github.com/zheka156/market_data/internal/integration/binance/binancetest"
+	"go.uber.org/zap"
+)
+
+func newTestClient(t *testing.T) (*binance.Client, *binancetest.Server) {
+	t.Helper()
+	fake, server := binancetest.NewServer()
+	t.Cleanup(server.Close)
+	t.Setenv("BINANCE_URL", server.URL)
+
+	conf := &config.Config{Binance: config.Binance{MaxRetries: 2, MaxRetryWait: 2 * time.Second}}
+	return binance.NewClient(zap.NewNop(), conf), fake
+}
+
+func TestGetBatchOfLastPrice(t *testing.T) {
+	client, fake := newTestClient(t)
+	fake.SetPrice("BTC", "USDT", "65000.5")
+	fake.SetPrice("ETH", "BTC", "0.05")
+
+	pairs, err := client.GetBatchOfLastPrice(`["BTCUSDT","ETHBTC"]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(pairs) != 2 {
+		t.Fatalf("expected 2 pairs, got %d", len(pairs))
+	}
+	got := map[string]string{}
+	for _, pair := range pairs {
+		got[pair.Symbol] = pair.Price
+		if time.Since(pair.Time) > time.Minute {
+			t.Errorf("expected server time for %s, got %s", pair.Symbol, pair.Time)
+		}
+	}
+	if got["BTCUSDT"] != "65000.50000000" || got["ETHBTC"] != "0.05000000" {
+		t.Errorf("unexpected prices %v", got)
+	}
+}
+
+func TestGetBatchOfLastPriceInvalidSymbol(t *testing.T) {
+	client, fake := newTestClient(t)
+	fake.SetPrice("BTC", "USDT", "65000")
+
+	_, err := client.GetBatchOfLastPrice(`["BTCUSDT","XYZUSDT"]`)
+	if !errors.Is(err, binance.ErrInvalidSymbol) {
+		t.Fatalf("expected invalid symbol error, got %v", err)
+	}
+}
+
+func TestGetLastPrice(t *testing.T) {
+	client, fake := newTestClient(t)
+	fake.SetPrice("SOL", "FDUSD", "151.2")
+
+	price, err := client.GetLastPrice("SOL", "FDUSD")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if price != "151.20000000" {
+		t.Errorf("expected 151.20000000, got %s", price)
+	}
+}
+
+// hourlyKlines are n klines from start priced 100, 101 and so on
+func hourlyKlines(start time.Time, n int) []binance.Kline {
+	klines := make([]binance.Kline, 0, n)
+	for i := 0; i < n; i++ {
+		open := start.Add(time.Duration(i) * time.Hour)
+		price := decimal.NewFromInt(int64(100 + i))
+		klines = append(klines, binance.Kline{
+			OpenTime:  open,
+			Open:      price,
+			High:      price,
+			Low:       price,
+			Close:     price,
+			CloseTime: open.Add(time.Hour - time.Millisecond),
+		})
+	}
+	return klines
+}
+
+func TestGetKlinesPaginates(t *testing.T) {
+	client, fake := newTestClient(t)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	klines := hourlyKlines(start, 2500)
+	fake.SetPrice("BTC", "USDT", "100")
+	fake.SetKlines("BTC", "USDT", klines)
+
+	got, err := client.GetKlines(binance.KlineParams{Symbol: "BTCUSDT", Interval: "1h", Start: start})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(got) != len(klines) {
+		t.Fatalf("expected %d klines, got %d", len(klines), len(got))
+	}
+	if !got[2499].Open.Equal(decimal.NewFromInt(2599)) || !got[2499].OpenTime.Equal(klines[2499].OpenTime) {
+		t.Errorf("unexpected last kline %+v", got[2499])
+	}
+	if requests := fake.Requests("/api/v3/klines"); requests != 3 {
+		t.Errorf("expected 3 pages, got %d requests", requests)
+	}
+}
+
+func TestGetKlinesLatestPagesBackwards(t *testing.T) {
+	client, fake := newTestClient(t)
+	klines := hourlyKlines(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), 3000)
+	fake.SetPrice("BTC", "USDT", "100")
+	fake.SetKlines("BTC", "USDT", klines)
+
+	got, err := client.GetKlines(binance.KlineParams{Symbol: "BTCUSDT", Interval: "1h", Limit: 2500})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(got) != 2500 {
+		t.Fatalf("expected 2500 klines, got %d", len(got))
+	}
+	if !got[0].OpenTime.Equal(klines[500].OpenTime) || !got[2499].OpenTime.Equal(klines[2999].OpenTime) {
+		t.Errorf("expected the latest klines, got %s to %s", got[0].OpenTime, got[2499].OpenTime)
+	}
+	for i := 1; i < len(got); i++ {
+		if !got[i].OpenTime.After(got[i-1].OpenTime) {
+			t.Fatalf("klines are not ordered at %d", i)
+		}
+	}
+	if requests := fake.Requests("/api/v3/klines"); requests != 3 {
+		t.Errorf("expected 3 pages, got %d requests", requests)
+	}
+
+	if _, err := client.GetKlines(binance.KlineParams{Symbol: "BTCUSDT", Interval: "2m"}); !errors.Is(err, binance.ErrInvalidInterval) {
+		t.Errorf("expected invalid interval error, got %v", err)
+	}
+}
+
+func TestGetExchangeInfo(t *testing.T) {
+	client, fake := newTestClient(t)
+	fake.SetPrice("BTC", "USDT", "65000")
+
+	info, err := client.GetExchangeInfo()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(info.Symbols) != 1 {
+		t.Fatalf("expected 1 symbol, got %d", len(info.Symbols))
+	}
+	symbol := info.Symbols[0]
+	if symbol.BaseAsset != "BTC" || symbol.QuoteAsset != "USDT" || symbol.Status != binance.SymbolStatusTrading {
+		t.Errorf("unexpected symbol %+v", symbol)
+	}
+	if symbol.PricePrecision() != 2 || symbol.QuantityPrecision() != 5 {
+		t.Errorf("unexpected precision %d/%d", symbol.PricePrecision(), symbol.QuantityPrecision())
+	}
+}
+
+func TestGetBatchOf24hrStats(t *testing.T) {
+	client, fake := newTestClient(t)
+	fake.SetPrice("BTC", "USDT", "65000")
+	fake.SetStats("BTC", "USDT", binance.TickerStats{
+		PriceChangePercent: decimal.RequireFromString("-2.5"),
+		HighPrice:          decimal.NewFromInt(67000),
+		LowPrice:           decimal.NewFromInt(64000),
+		OpenTime:           time.UnixMilli(1_700_000_000_000),
+	})
+
+	stats, err := client.GetBatchOf24hrStats(`["BTCUSDT"]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(stats) != 1 {
+		t.Fatalf("expected 1 stats, got %d", len(stats))
+	}
+	if !stats[0].PriceChangePercent.Equal(decimal.RequireFromString("-2.5")) || !stats[0].HighPrice.Equal(decimal.NewFromInt(67000)) {
+		t.Errorf("unexpected stats %+v", stats[0])
+	}
+	if stats[0].OpenTime.UnixMilli() != 1_700_000_000_000 {
+		t.Errorf("unexpected open time %s", stats[0].OpenTime)
+	}
+}
+
+func TestRateLimitIsRetried(t *testing.T) {
+	client, fake := newTestClient(t)
+	fake.SetPrice("BTC", "USDT", "65000")
+	fake.RateLimit(1, time.Second)
+
+	_, err := client.GetBatchOfLastPrice(`["BTCUSDT"]`)
+	if err != nil {
+		t.Fatalf("expected the request to succeed after retry, got %s", err)
+	}
+	if requests := fake.Requests("/api/v3/ticker/price"); requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+}
+
+func TestServerErrorIsRetried(t *testing.T) {
+	client, fake := newTestClient(t)
+	fake.SetPrice("BTC", "USDT", "65000")
+	fake.FailServer(1)
+
+	_, err := client.GetBatchOfLastPrice(`["BTCUSDT"]`)
+	if err != nil {
+		t.Fatalf("expected the request to succeed after retry, got %s", err)
+	}
+}
+
+func TestRateLimitOverMaxWaitIsNotRetried(t *testing.T) {
+	client, fake := newTestClient(t)
+	fake.SetPrice("BTC", "USDT", "65000")
+	fake.RateLimit(1, time.Minute)
+
+	_, err := client.GetBatchOfLastPrice(`["BTCUSDT"]`)
+	if !errors.Is(err, binance.ErrRateLimited) {
+		t.Fatalf("expected rate limit error, got %v", err)
+	}
+	var apiErr *binance.APIError
+	if !errors.As(err, &apiErr) || apiErr.RetryAfter != time.Minute {
+		t.Errorf("expected retry after a minute, got %v", err)
+	}
+}
+
+func TestBanFailsFast(t *testing.T) {
+	client, fake := newTestClient(t)
+	fake.SetPrice("BTC", "USDT", "65000")
+	fake.Ban(1, 2*time.Minute)
+
+	_, err := client.GetBatchOfLastPrice(`["BTCUSDT"]`)
+	if !errors.Is(err, binance.ErrBanned) {
+		t.Fatalf("expected ban error, got %v", err)
+	}
+
+	// binance is not called again while the ban lasts
+	_, err = client.GetBatchOfLastPrice(`["BTCUSDT"]`)
+	if !errors.Is(err, binance.ErrBanned) {
+		t.Fatalf("expected ban error, got %v", err)
+	}
+	if requests := fake.Requests("/api/v3/ticker/price"); requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+}
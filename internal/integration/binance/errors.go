@@ -0,0 +1,83 @@
+package binance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	codeTooManyRequests = -1003
+	codeInvalidSymbol   = -1121
+)
+
+var (
+	ErrInvalidSymbol = errors.New("invalid symbol")
+	ErrRateLimited   = errors.New("rate limited by binance")
+	ErrBanned        = errors.New("ip banned by binance")
+)
+
+// APIError is the error payload binance sends with unsuccessful responses.
+// Use errors.Is with ErrInvalidSymbol, ErrRateLimited and ErrBanned to check the kind.
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Code       int    `json:"code"`
+	Message    string `json:"msg"`
+	// RetryAfter is set for rate limit and ban responses
+	RetryAfter time.Duration `json:"-"`
+}
+
+func (e *APIError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("binance error %d (http %d): %s, retry after %s", e.Code, e.StatusCode, e.Message, e.RetryAfter)
+	}
+	return fmt.Sprintf("binance error %d (http %d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrInvalidSymbol:
+		return e.Code == codeInvalidSymbol
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests || e.Code == codeTooManyRequests
+	case ErrBanned:
+		return e.StatusCode == http.StatusTeapot
+	}
+	return false
+}
+
+// parseAPIError builds an error from an unsuccessful response
+func parseAPIError(response *resty.Response) error {
+	apiErr := &APIError{StatusCode: response.StatusCode()}
+	if apiErr.Is(ErrRateLimited) || apiErr.Is(ErrBanned) {
+		apiErr.RetryAfter = retryAfter(response)
+	}
+	if err := json.Unmarshal(response.Body(), apiErr); err != nil || apiErr.Message == "" {
+		apiErr.Message = response.Status()
+	}
+	return apiErr
+}
+
+// decodeResponse checks the status and unmarshals the body into v,
+// binance error payloads are returned as *APIError
+func decodeResponse(response *resty.Response, v any) error {
+	if response.IsError() {
+		return parseAPIError(response)
+	}
+
+	// an error payload would be silently decoded into an empty struct
+	var probe APIError
+	if json.Unmarshal(response.Body(), &probe) == nil && probe.Code != 0 && probe.Message != "" {
+		probe.StatusCode = response.StatusCode()
+		return &probe
+	}
+
+	if err := json.Unmarshal(response.Body(), v); err != nil {
+		return fmt.Errorf("failed to decode binance response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package binance
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const SymbolStatusTrading = "TRADING"
+
+type ExchangeInfo struct {
+	Symbols []SymbolInfo `json:"symbols"`
+}
+
+type SymbolInfo struct {
+	Symbol     string         `json:"symbol"`
+	Status     string         `json:"status"`
+	BaseAsset  string         `json:"baseAsset"`
+	QuoteAsset string         `json:"quoteAsset"`
+	Filters    []SymbolFilter `json:"filters"`
+}
+
+type SymbolFilter struct {
+	FilterType string `json:"filterType"`
+	TickSize   string `json:"tickSize,omitempty"`
+	StepSize   string `json:"stepSize,omitempty"`
+}
+
+// PricePrecision is the number of decimals allowed by the tick size
+func (s SymbolInfo) PricePrecision() int {
+	return s.filterPrecision("PRICE_FILTER", func(f SymbolFilter) string { return f.TickSize })
+}
+
+// QuantityPrecision is the number of decimals allowed by the lot step size
+func (s SymbolInfo) QuantityPrecision() int {
+	return s.filterPrecision("LOT_SIZE", func(f SymbolFilter) string { return f.StepSize })
+}
+
+func (s SymbolInfo) filterPrecision(filterType string, value func(SymbolFilter) string) int {
+	for _, f := range s.Filters {
+		if f.FilterType == filterType {
+			return stepPrecision(value(f))
+		}
+	}
+	return defaultPrecision
+}
+
+const defaultPrecision = 8
+
+// stepPrecision converts a step like "0.00100000" to the number of decimals (3)
+func stepPrecision(step string) int {
+	dot := strings.IndexByte(step, '.')
+	if dot == -1 {
+		return 0
+	}
+	decimals := strings.TrimRight(step[dot+1:], "0")
+	return len(decimals)
+}
+
+// GetExchangeInfo returns spot symbols with TRADING status
+func (c *Client) GetExchangeInfo() (*ExchangeInfo, error) {
+	var response ExchangeInfo
+	resp, err := c.R().
+		SetQueryParam("permissions", "SPOT").
+		SetQueryParam("symbolStatus", SymbolStatusTrading).
+		Get("/api/v3/exchangeInfo")
+	if err != nil {
+		c.logger.Error("Failed to get exchange info", zap.Error(err))
+		return nil, err
+	}
+	err = decodeResponse(resp, &response)
+	if err != nil {
+		c.logger.Error("Failed to decode response", zap.Error(err))
+		return nil, err
+	}
+	return &response, nil
+}
@@ -0,0 +1,191 @@
+package binance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// binance returns at most this many klines per request
+const maxKlinesPerRequest = 1000
+
+// ErrInvalidInterval is returned for interval names binance doesn't serve
+var ErrInvalidInterval = errors.New("invalid kline interval")
+
+// Kline is a single candlestick, binance sends it as a json array
+type Kline struct {
+	OpenTime    time.Time
+	Open        decimal.Decimal
+	High        decimal.Decimal
+	Low         decimal.Decimal
+	Close       decimal.Decimal
+	Volume      decimal.Decimal
+	CloseTime   time.Time
+	QuoteVolume decimal.Decimal
+	Trades      int64
+}
+
+func (k *Kline) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw) < 9 {
+		return fmt.Errorf("unexpected kline length %d", len(raw))
+	}
+
+	var openTime, closeTime int64
+	fields := []any{&openTime, &k.Open, &k.High, &k.Low, &k.Close, &k.Volume, &closeTime, &k.QuoteVolume, &k.Trades}
+	for i, field := range fields {
+		if err := json.Unmarshal(raw[i], field); err != nil {
+			return fmt.Errorf("failed to unmarshal kline field %d: %w", i, err)
+		}
+	}
+	k.OpenTime = time.UnixMilli(openTime).UTC()
+	k.CloseTime = time.UnixMilli(closeTime).UTC()
+	return nil
+}
+
+type KlineParams struct {
+	// Symbol is the binance pair, e.g. BTCUSDT
+	Symbol   string
+	Interval string
+	// Start and End limit open times of the klines, zero values are not sent
+	Start time.Time
+	End   time.Time
+	// Limit is the total number of klines, it can exceed the per request cap.
+	// Zero means one request with the binance default limit, or all klines up to End if Start is set.
+	Limit int
+}
+
+// GetKlines returns candles ordered by open time, pages are requested until Limit or End is reached.
+// Without Start the latest Limit klines up to End are returned, pages are requested backwards then.
+func (c *Client) GetKlines(params KlineParams) ([]Kline, error) {
+	if !validKlineInterval(params.Interval) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidInterval, params.Interval)
+	}
+	if params.Start.IsZero() && params.Limit > maxKlinesPerRequest {
+		return c.getLatestKlines(params)
+	}
+
+	var klines []Kline
+	start := params.Start
+	paginate := params.Limit > 0 || !params.Start.IsZero()
+
+	for {
+		pageLimit := maxKlinesPerRequest
+		if params.Limit > 0 {
+			pageLimit = min(params.Limit-len(klines), maxKlinesPerRequest)
+		}
+
+		page, err := c.getKlinesPage(params, start, params.End, pageLimit, paginate)
+		if err != nil {
+			return nil, err
+		}
+		klines = append(klines, page...)
+
+		if !paginate || len(page) < pageLimit || (params.Limit > 0 && len(klines) >= params.Limit) {
+			return klines, nil
+		}
+		start = page[len(page)-1].OpenTime.Add(time.Millisecond)
+		if !params.End.IsZero() && start.After(params.End) {
+			return klines, nil
+		}
+	}
+}
+
+// getLatestKlines pages back from End, binance answers a request without startTime with the latest klines
+func (c *Client) getLatestKlines(params KlineParams) ([]Kline, error) {
+	var pages [][]Kline
+	fetched := 0
+	end := params.End
+	for fetched < params.Limit {
+		pageLimit := min(params.Limit-fetched, maxKlinesPerRequest)
+		page, err := c.getKlinesPage(params, time.Time{}, end, pageLimit, true)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, page)
+		fetched += len(page)
+		if len(page) < pageLimit {
+			break
+		}
+		end = page[0].OpenTime.Add(-time.Millisecond)
+	}
+
+	klines := make([]Kline, 0, fetched)
+	for i := len(pages) - 1; i >= 0; i-- {
+		klines = append(klines, pages[i]...)
+	}
+	return klines, nil
+}
+
+func (c *Client) getKlinesPage(params KlineParams, start time.Time, end time.Time, limit int, withLimit bool) ([]Kline, error) {
+	var response []Kline
+	req := c.R().
+		SetQueryParam("symbol", params.Symbol).
+		SetQueryParam("interval", params.Interval)
+	if !start.IsZero() {
+		req.SetQueryParam("startTime", strconv.FormatInt(start.UnixMilli(), 10))
+	}
+	if !end.IsZero() {
+		req.SetQueryParam("endTime", strconv.FormatInt(end.UnixMilli(), 10))
+	}
+	if withLimit {
+		req.SetQueryParam("limit", strconv.Itoa(limit))
+	}
+
+	resp, err := req.Get("/api/v3/klines")
+	if err != nil {
+		c.logger.Error("Failed to get klines", zap.Error(err))
+		return nil, err
+	}
+	err = decodeResponse(resp, &response)
+	if err != nil {
+		c.logger.Error("Failed to decode response", zap.Error(err))
+		return nil, err
+	}
+	return response, nil
+}
+
+// klineIntervals are the binance kline intervals by name, 1M is a calendar month without a fixed duration
+var klineIntervals = map[string]time.Duration{
+	"1s":  time.Second,
+	"1m":  time.Minute,
+	"3m":  3 * time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"30m": 30 * time.Minute,
+	"1h":  time.Hour,
+	"2h":  2 * time.Hour,
+	"4h":  4 * time.Hour,
+	"6h":  6 * time.Hour,
+	"8h":  8 * time.Hour,
+	"12h": 12 * time.Hour,
+	"1d":  24 * time.Hour,
+	"3d":  3 * 24 * time.Hour,
+	"1w":  7 * 24 * time.Hour,
+	"1M":  0,
+}
+
+func validKlineInterval(interval string) bool {
+	_, ok := klineIntervals[interval]
+	return ok
+}
+
+// KlineInterval converts a duration to the binance kline interval notation
+func KlineInterval(d time.Duration) (string, error) {
+	if d > 0 {
+		for name, duration := range klineIntervals {
+			if duration == d {
+				return name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("unsupported kline interval %s", d)
+}
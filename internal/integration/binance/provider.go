@@ -0,0 +1,192 @@
+package binance
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/provider"
+	"go.uber.org/zap"
+)
+
+const (
+	ProviderName = "binance"
+	// binance accepts more, the batch is kept small so a rejected symbol is cheap to bisect
+	maxSymbolsPerBatch = 20
+)
+
+// Provider serves crypto prices from binance spot pairs
+type Provider struct {
+	client Binance
+	log    *zap.Logger
+	volume bool
+}
+
+func NewProvider(logger *zap.Logger, client Binance) *Provider {
+	return &Provider{client: client, log: logger}
+}
+
+// SetVolume makes batch quotes carry the 24 hour volume for volume weighted consensus,
+// they are read from the 24 hour ticker then, which weighs as much as the price ticker per batch
+func (p *Provider) SetVolume(enabled bool) {
+	p.volume = enabled
+}
+
+func (p *Provider) Name() string {
+	return ProviderName
+}
+
+func (p *Provider) LastPrice(asset provider.Asset) (*provider.Quote, error) {
+	raw, err := p.client.GetLastPrice(asset.Symbol, asset.Quote)
+	if err != nil {
+		return nil, unknownAsset(err)
+	}
+	price, err := decimal.NewFromString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s price %q: %w", asset, raw, err)
+	}
+	return &provider.Quote{Asset: asset, Price: price, Time: time.Now().UTC(), Source: ProviderName}, nil
+}
+
+// BatchLastPrice requests prices in batches. Binance rejects the whole batch if one symbol is invalid,
+// in that case the batch is bisected and the offending assets are returned as unknown.
+func (p *Provider) BatchLastPrice(assets []provider.Asset) ([]provider.Quote, []provider.Asset, error) {
+	var quotes []provider.Quote
+	var unknown []provider.Asset
+	for i := 0; i < len(assets); i += maxSymbolsPerBatch {
+		end := min(i+maxSymbolsPerBatch, len(assets))
+		fetched, rejected, err := p.fetchBatch(assets[i:end])
+		if err != nil {
+			return nil, nil, err
+		}
+		quotes = append(quotes, fetched...)
+		unknown = append(unknown, rejected...)
+	}
+	return quotes, unknown, nil
+}
+
+func (p *Provider) fetchBatch(chunk []provider.Asset) ([]provider.Quote, []provider.Asset, error) {
+	bySymbol := make(map[string]provider.Asset, len(chunk))
+	symbols := make([]string, 0, len(chunk))
+	for _, asset := range chunk {
+		symbol := Symbol(asset.Symbol, asset.Quote)
+		bySymbol[symbol] = asset
+		symbols = append(symbols, fmt.Sprintf("%q", symbol))
+	}
+
+	quotes, err := p.quoteBatch(bySymbol, "["+strings.Join(symbols, ",")+"]")
+	if err == nil {
+		return quotes, nil, nil
+	}
+	if !errors.Is(err, ErrInvalidSymbol) {
+		return nil, nil, err
+	}
+	if len(chunk) == 1 {
+		p.log.Warn("Invalid symbol detected", zap.Stringer("asset", chunk[0]))
+		return nil, chunk, nil
+	}
+
+	middle := len(chunk) / 2
+	left, leftInvalid, err := p.fetchBatch(chunk[:middle])
+	if err != nil {
+		return nil, nil, err
+	}
+	right, rightInvalid, err := p.fetchBatch(chunk[middle:])
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(left, right...), append(leftInvalid, rightInvalid...), nil
+}
+
+// quoteBatch quotes the symbols of the json array, with their volume if it is enabled
+func (p *Provider) quoteBatch(bySymbol map[string]provider.Asset, symbols string) ([]provider.Quote, error) {
+	if p.volume {
+		stats, err := p.client.GetBatchOf24hrStats(symbols)
+		if err != nil {
+			return nil, err
+		}
+		quotes := make([]provider.Quote, 0, len(stats))
+		for _, s := range stats {
+			if asset, ok := bySymbol[s.Symbol]; ok {
+				quotes = append(quotes, provider.Quote{Asset: asset, Price: s.LastPrice, Time: s.CloseTime, Source: ProviderName, Volume: s.Volume})
+			}
+		}
+		return quotes, nil
+	}
+
+	pairs, err := p.client.GetBatchOfLastPrice(symbols)
+	if err != nil {
+		return nil, err
+	}
+	quotes := make([]provider.Quote, 0, len(pairs))
+	for _, pair := range pairs {
+		asset, ok := bySymbol[pair.Symbol]
+		if !ok {
+			continue
+		}
+		price, err := decimal.NewFromString(pair.Price)
+		if err != nil {
+			p.log.Warn("Failed to parse price", zap.String("symbol", pair.Symbol), zap.String("price", pair.Price), zap.Error(err))
+			continue
+		}
+		quotes = append(quotes, provider.Quote{Asset: asset, Price: price, Time: pair.Time, Source: ProviderName})
+	}
+	return quotes, nil
+}
+
+func (p *Provider) History(asset provider.Asset, from time.Time, to time.Time, interval time.Duration) ([]provider.Candle, error) {
+	klineInterval, err := KlineInterval(interval)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", provider.ErrUnsupported, err)
+	}
+	klines, err := p.client.GetKlines(KlineParams{
+		Symbol:   Symbol(asset.Symbol, asset.Quote),
+		Interval: klineInterval,
+		Start:    from,
+		End:      to,
+	})
+	if err != nil {
+		return nil, unknownAsset(err)
+	}
+	candles := make([]provider.Candle, 0, len(klines))
+	for _, k := range klines {
+		candles = append(candles, provider.Candle{
+			OpenTime: k.OpenTime,
+			Open:     k.Open,
+			High:     k.High,
+			Low:      k.Low,
+			Close:    k.Close,
+			Volume:   k.Volume,
+		})
+	}
+	return candles, nil
+}
+
+func (p *Provider) SupportedSymbols() ([]provider.Asset, error) {
+	info, err := p.client.GetExchangeInfo()
+	if err != nil {
+		return nil, err
+	}
+	assets := make([]provider.Asset, 0, len(info.Symbols))
+	for _, symbol := range info.Symbols {
+		if symbol.Status != SymbolStatusTrading {
+			continue
+		}
+		assets = append(assets, provider.Asset{Symbol: symbol.BaseAsset, Quote: symbol.QuoteAsset, Class: provider.Crypto})
+	}
+	return assets, nil
+}
+
+func (p *Provider) Health() error {
+	return p.client.Ping()
+}
+
+// unknownAsset marks invalid symbol errors so callers don't depend on binance errors
+func unknownAsset(err error) error {
+	if errors.Is(err, ErrInvalidSymbol) {
+		return fmt.Errorf("%w: %w", provider.ErrUnknownAsset, err)
+	}
+	return err
+}
@@ -0,0 +1,37 @@
+package binance_test
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/integration/binance"
+	"github.com/zheka156/market_data/internal/provider"
+	"github.com/zheka156/market_data/internal/provider/providertest"
+	"go.uber.org/zap"
+)
+
+func TestBatchLastPriceVolume(t *testing.T) {
+	client, fake := newTestClient(t)
+	fake.SetPrice("BTC", "USDT", "65000")
+	fake.SetStats("BTC", "USDT", binance.TickerStats{LastPrice: decimal.NewFromInt(65000), Volume: decimal.NewFromInt(1200)})
+	fake.SetPrice("ETH", "USDT", "3200")
+	p := binance.NewProvider(zap.NewNop(), client)
+	assets := []provider.Asset{providertest.Crypto("BTC", "USDT"), providertest.Crypto("DEAD", "USDT")}
+
+	quotes, unknown, err := p.BatchLastPrice(assets)
+	if err != nil || len(quotes) != 1 || len(unknown) != 1 {
+		t.Fatalf("expected BTC quoted and DEAD unknown, got %+v %+v (%v)", quotes, unknown, err)
+	}
+	if !quotes[0].Volume.IsZero() || fake.Requests("/api/v3/ticker/24hr") != 0 {
+		t.Errorf("expected price ticker quotes without volume, got %+v", quotes[0])
+	}
+
+	p.SetVolume(true)
+	quotes, unknown, err = p.BatchLastPrice(assets)
+	if err != nil || len(quotes) != 1 || len(unknown) != 1 {
+		t.Fatalf("expected BTC quoted and DEAD unknown, got %+v %+v (%v)", quotes, unknown, err)
+	}
+	if !quotes[0].Price.Equal(decimal.NewFromInt(65000)) || !quotes[0].Volume.Equal(decimal.NewFromInt(1200)) {
+		t.Errorf("expected BTC at 65000 with a volume of 1200, got %+v", quotes[0])
+	}
+}
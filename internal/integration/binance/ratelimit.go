@@ -0,0 +1,173 @@
+package binance
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/zheka156/market_data/internal/config"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultWeightLimit     = 6000
+	defaultWeightThreshold = 0.9
+	defaultMaxRetries      = 3
+	defaultMaxRetryWait    = time.Minute
+	minRetryWait           = 500 * time.Millisecond
+	// used when binance doesn't send Retry-After
+	defaultRetryAfter = 10 * time.Second
+
+	usedWeightHeader = "X-Mbx-Used-Weight-1m"
+)
+
+// limiter follows the request weight reported by binance and pauses requests
+// before the minute limit is hit or while binance asked to back off
+type limiter struct {
+	mu           sync.Mutex
+	log          *zap.Logger
+	limit        int
+	threshold    float64
+	usedWeight   int
+	weightMinute time.Time
+	blockedUntil time.Time
+	banned       bool
+}
+
+func newLimiter(logger *zap.Logger, conf config.Binance) *limiter {
+	l := &limiter{
+		log:       logger,
+		limit:     conf.WeightLimit,
+		threshold: conf.WeightThreshold,
+	}
+	if l.limit <= 0 {
+		l.limit = defaultWeightLimit
+	}
+	if l.threshold <= 0 || l.threshold > 1 {
+		l.threshold = defaultWeightThreshold
+	}
+	return l
+}
+
+// wait blocks until a request is allowed, requests fail right away during an IP ban
+func (l *limiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	var until time.Time
+	switch {
+	case now.Before(l.blockedUntil) && l.banned:
+		err := &APIError{StatusCode: http.StatusTeapot, Message: "ip is banned by binance", RetryAfter: time.Until(l.blockedUntil)}
+		l.mu.Unlock()
+		return err
+	case now.Before(l.blockedUntil):
+		until = l.blockedUntil
+	case now.Truncate(time.Minute).Equal(l.weightMinute) && float64(l.usedWeight) >= float64(l.limit)*l.threshold:
+		until = l.weightMinute.Add(time.Minute)
+		l.log.Warn("Binance request weight is close to the limit, throttling",
+			zap.Int("used_weight", l.usedWeight), zap.Int("limit", l.limit), zap.Time("until", until))
+	}
+	l.mu.Unlock()
+
+	if until.IsZero() {
+		return nil
+	}
+	timer := time.NewTimer(time.Until(until))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// observe records the used weight and back off signals of a response
+func (l *limiter) observe(response *resty.Response) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if used, err := strconv.Atoi(response.Header().Get(usedWeightHeader)); err == nil {
+		l.usedWeight = used
+		l.weightMinute = time.Now().Truncate(time.Minute)
+	}
+
+	status := response.StatusCode()
+	if status != http.StatusTooManyRequests && status != http.StatusTeapot {
+		return
+	}
+	until := time.Now().Add(retryAfter(response))
+	if until.After(l.blockedUntil) {
+		l.blockedUntil = until
+	}
+	l.banned = status == http.StatusTeapot
+	l.log.Warn("Binance asked to back off",
+		zap.Int("status", status), zap.Time("until", l.blockedUntil), zap.Bool("banned", l.banned))
+}
+
+// setRetryPolicy retries rate limits, server errors and timeouts.
+// Waits longer than MaxRetryWait are not retried and surface as typed errors.
+func setRetryPolicy(c *resty.Client, logger *zap.Logger, conf config.Binance, l *limiter) {
+	maxRetries := conf.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultMaxRetries
+	}
+	maxWait := conf.MaxRetryWait
+	if maxWait <= 0 {
+		maxWait = defaultMaxRetryWait
+	}
+
+	c.SetRetryCount(maxRetries)
+	c.SetRetryWaitTime(minRetryWait)
+	c.SetRetryMaxWaitTime(maxWait)
+
+	c.OnBeforeRequest(func(client *resty.Client, request *resty.Request) error {
+		return l.wait(request.Context())
+	})
+	c.OnAfterResponse(func(client *resty.Client, response *resty.Response) error {
+		l.observe(response)
+		return nil
+	})
+
+	c.AddRetryCondition(func(response *resty.Response, err error) bool {
+		if err != nil {
+			// transport errors and timeouts are retried, a ban or a cancelled caller are not
+			var apiErr *APIError
+			return !errors.As(err, &apiErr) && !errors.Is(err, context.Canceled)
+		}
+		status := response.StatusCode()
+		return status == http.StatusTooManyRequests || status == http.StatusTeapot || status >= http.StatusInternalServerError
+	})
+
+	c.SetRetryAfter(func(client *resty.Client, response *resty.Response) (time.Duration, error) {
+		status := response.StatusCode()
+		if status != http.StatusTooManyRequests && status != http.StatusTeapot {
+			// exponential backoff with jitter
+			return 0, nil
+		}
+		wait := retryAfter(response)
+		if wait > maxWait {
+			return 0, parseAPIError(response)
+		}
+		return wait, nil
+	})
+
+	c.AddRetryHook(func(response *resty.Response, err error) {
+		fields := []zap.Field{zap.Error(err)}
+		if response != nil {
+			fields = append(fields, zap.Int("status", response.StatusCode()), zap.String("url", response.Request.URL))
+		}
+		logger.Warn("Retrying binance request", fields...)
+	})
+}
+
+func retryAfter(response *resty.Response) time.Duration {
+	seconds, err := strconv.Atoi(response.Header().Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return defaultRetryAfter
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,296 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/config"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultStreamURL     = "wss://data-stream.binance.vision"
+	defaultStreamType    = StreamMiniTicker
+	defaultStreamRefresh = 10 * time.Minute
+	StreamMiniTicker     = "miniTicker"
+	StreamTrade          = "trade"
+
+	// binance drops connections after 24 hours, reconnect a bit earlier
+	maxConnectionAge = 23 * time.Hour
+	// binance pings every 20 seconds, the connection is considered dead without traffic
+	streamReadTimeout  = time.Minute
+	streamWriteTimeout = 10 * time.Second
+
+	maxStreamsPerConnection = 1024
+	maxStreamsPerMessage    = 100
+	// binance accepts up to 5 incoming messages per second
+	subscribeMessageDelay = 250 * time.Millisecond
+
+	minReconnectDelay = time.Second
+	maxReconnectDelay = time.Minute
+	stableConnection  = 5 * time.Minute
+)
+
+var errConnectionExpired = errors.New("connection reached max age")
+
+// StreamUpdate is a live price from the market stream
+type StreamUpdate struct {
+	// Symbol is the binance pair, e.g. BTCUSDT
+	Symbol    string
+	Price     decimal.Decimal
+	EventTime time.Time
+}
+
+// Stream keeps a websocket connection to the binance market streams
+// subscribed to the pairs returned by symbols, reconnecting when it is dropped
+type Stream struct {
+	url        string
+	streamType string
+	refresh    time.Duration
+	log        *zap.Logger
+	symbols    func() ([]string, error)
+	handler    func(StreamUpdate)
+
+	writeMu    sync.Mutex
+	subscribed map[string]struct{}
+	nextID     int64
+}
+
+func NewStream(logger *zap.Logger, conf config.BinanceStream, symbols func() ([]string, error), handler func(StreamUpdate)) *Stream {
+	s := &Stream{
+		url:        strings.TrimSuffix(conf.URL, "/"),
+		streamType: conf.Type,
+		refresh:    conf.RefreshInterval,
+		log:        logger,
+		symbols:    symbols,
+		handler:    handler,
+	}
+	if s.url == "" {
+		s.url = defaultStreamURL
+	}
+	if s.streamType == "" {
+		s.streamType = defaultStreamType
+	}
+	if s.refresh <= 0 {
+		s.refresh = defaultStreamRefresh
+	}
+	return s
+}
+
+// Run blocks until ctx is done
+func (s *Stream) Run(ctx context.Context) {
+	delay := minReconnectDelay
+	for {
+		started := time.Now()
+		err := s.session(ctx)
+		if ctx.Err() != nil {
+			s.log.Info("Binance stream is stopped")
+			return
+		}
+		if errors.Is(err, errConnectionExpired) {
+			s.log.Info("Binance stream connection expired, reconnecting")
+			delay = minReconnectDelay
+			continue
+		}
+		if time.Since(started) > stableConnection {
+			delay = minReconnectDelay
+		}
+		s.log.Error("Binance stream connection lost", zap.Error(err), zap.Duration("reconnect_in", delay))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+func (s *Stream) session(ctx context.Context) error {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, s.url+"/ws", nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", s.url, err)
+	}
+	defer conn.Close()
+	s.log.Info("Binance stream connected", zap.String("url", s.url))
+
+	s.subscribed = make(map[string]struct{})
+	conn.SetReadDeadline(time.Now().Add(streamReadTimeout))
+	conn.SetPingHandler(func(data string) error {
+		conn.SetReadDeadline(time.Now().Add(streamReadTimeout))
+		s.writeMu.Lock()
+		defer s.writeMu.Unlock()
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(streamWriteTimeout))
+	})
+
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- s.read(conn)
+	}()
+
+	if err := s.syncSubscriptions(conn); err != nil {
+		conn.Close()
+		<-readErr
+		return err
+	}
+
+	expire := time.NewTimer(maxConnectionAge)
+	defer expire.Stop()
+	refresh := time.NewTicker(s.refresh)
+	defer refresh.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.close(conn)
+			<-readErr
+			return ctx.Err()
+		case err := <-readErr:
+			return err
+		case <-expire.C:
+			s.close(conn)
+			<-readErr
+			return errConnectionExpired
+		case <-refresh.C:
+			if err := s.syncSubscriptions(conn); err != nil {
+				s.log.Error("Failed to refresh binance stream subscriptions", zap.Error(err))
+			}
+		}
+	}
+}
+
+// streamMessage covers subscription responses, mini ticker and trade events
+type streamMessage struct {
+	ID    *int64 `json:"id"`
+	Error *struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	} `json:"error"`
+	Event     string `json:"e"`
+	EventTime int64  `json:"E"`
+	Symbol    string `json:"s"`
+	Close     string `json:"c"`
+	Price     string `json:"p"`
+}
+
+func (s *Stream) read(conn *websocket.Conn) error {
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		conn.SetReadDeadline(time.Now().Add(streamReadTimeout))
+
+		var msg streamMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			s.log.Warn("Failed to unmarshal stream message", zap.ByteString("message", data), zap.Error(err))
+			continue
+		}
+		if msg.ID != nil {
+			if msg.Error != nil {
+				s.log.Error("Binance stream request failed", zap.Int64("id", *msg.ID), zap.Int("code", msg.Error.Code), zap.String("msg", msg.Error.Msg))
+			}
+			continue
+		}
+
+		raw := msg.Close
+		if msg.Event == "trade" {
+			raw = msg.Price
+		}
+		price, err := decimal.NewFromString(raw)
+		if err != nil {
+			s.log.Warn("Failed to parse stream price", zap.String("symbol", msg.Symbol), zap.String("price", raw), zap.Error(err))
+			continue
+		}
+		s.handler(StreamUpdate{
+			Symbol:    msg.Symbol,
+			Price:     price,
+			EventTime: time.UnixMilli(msg.EventTime).UTC(),
+		})
+	}
+}
+
+// syncSubscriptions subscribes new pairs and unsubscribes pairs that are no longer tracked
+func (s *Stream) syncSubscriptions(conn *websocket.Conn) error {
+	symbols, err := s.symbols()
+	if err != nil {
+		return fmt.Errorf("failed to get stream symbols: %w", err)
+	}
+	if len(symbols) > maxStreamsPerConnection {
+		s.log.Warn("Too many stream symbols, extra are skipped", zap.Int("count", len(symbols)))
+		symbols = symbols[:maxStreamsPerConnection]
+	}
+
+	wanted := make(map[string]struct{}, len(symbols))
+	var toSubscribe, toUnsubscribe []string
+	for _, symbol := range symbols {
+		stream := strings.ToLower(symbol) + "@" + s.streamType
+		wanted[stream] = struct{}{}
+		if _, ok := s.subscribed[stream]; !ok {
+			toSubscribe = append(toSubscribe, stream)
+		}
+	}
+	for stream := range s.subscribed {
+		if _, ok := wanted[stream]; !ok {
+			toUnsubscribe = append(toUnsubscribe, stream)
+		}
+	}
+
+	if err := s.send(conn, "UNSUBSCRIBE", toUnsubscribe); err != nil {
+		return err
+	}
+	for _, stream := range toUnsubscribe {
+		delete(s.subscribed, stream)
+	}
+	if err := s.send(conn, "SUBSCRIBE", toSubscribe); err != nil {
+		return err
+	}
+	for _, stream := range toSubscribe {
+		s.subscribed[stream] = struct{}{}
+	}
+	if len(toSubscribe) != 0 || len(toUnsubscribe) != 0 {
+		s.log.Info("Binance stream subscriptions updated",
+			zap.Int("subscribed", len(toSubscribe)),
+			zap.Int("unsubscribed", len(toUnsubscribe)),
+			zap.Int("total", len(s.subscribed)))
+	}
+	return nil
+}
+
+func (s *Stream) send(conn *websocket.Conn, method string, streams []string) error {
+	for i := 0; i < len(streams); i += maxStreamsPerMessage {
+		end := min(i+maxStreamsPerMessage, len(streams))
+		s.nextID++
+		request := map[string]any{
+			"method": method,
+			"params": streams[i:end],
+			"id":     s.nextID,
+		}
+
+		s.writeMu.Lock()
+		conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		err := conn.WriteJSON(request)
+		s.writeMu.Unlock()
+		if err != nil {
+			return fmt.Errorf("failed to send %s: %w", method, err)
+		}
+		time.Sleep(subscribeMessageDelay)
+	}
+	return nil
+}
+
+func (s *Stream) close(conn *websocket.Conn) {
+	s.writeMu.Lock()
+	conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(streamWriteTimeout))
+	s.writeMu.Unlock()
+	conn.Close()
+}
@@ -0,0 +1,57 @@
+package binance
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// TickerStats is the rolling 24 hour window of a pair
+type TickerStats struct {
+	Symbol             string          `json:"symbol"`
+	PriceChange        decimal.Decimal `json:"priceChange"`
+	PriceChangePercent decimal.Decimal `json:"priceChangePercent"`
+	LastPrice          decimal.Decimal `json:"lastPrice"`
+	HighPrice          decimal.Decimal `json:"highPrice"`
+	LowPrice           decimal.Decimal `json:"lowPrice"`
+	Volume             decimal.Decimal `json:"volume"`
+	QuoteVolume        decimal.Decimal `json:"quoteVolume"`
+	OpenTime           time.Time       `json:"-"`
+	CloseTime          time.Time       `json:"-"`
+}
+
+func (s *TickerStats) UnmarshalJSON(data []byte) error {
+	type alias TickerStats
+	raw := struct {
+		*alias
+		OpenTime  int64 `json:"openTime"`
+		CloseTime int64 `json:"closeTime"`
+	}{alias: (*alias)(s)}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	s.OpenTime = time.UnixMilli(raw.OpenTime).UTC()
+	s.CloseTime = time.UnixMilli(raw.CloseTime).UTC()
+	return nil
+}
+
+// GetBatchOf24hrStats returns 24 hour statistics of the pairs, tickers is a json array of symbols
+func (c *Client) GetBatchOf24hrStats(tickers string) ([]TickerStats, error) {
+	var response []TickerStats
+	resp, err := c.R().
+		SetQueryParam("symbols", tickers).
+		SetQueryParam("type", "FULL").
+		Get("/api/v3/ticker/24hr")
+	if err != nil {
+		c.logger.Error("Failed to get 24hr stats", zap.Error(err))
+		return nil, err
+	}
+	err = decodeResponse(resp, &response)
+	if err != nil {
+		c.logger.Error("Failed to decode response", zap.Error(err))
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,111 @@
+package coinbase
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// coinbase returns at most this many candles per request
+const maxCandlesPerRequest = 300
+
+// Candle is a single candlestick, coinbase sends it as [time, low, high, open, close, volume]
+type Candle struct {
+	OpenTime time.Time
+	Low      decimal.Decimal
+	High     decimal.Decimal
+	Open     decimal.Decimal
+	Close    decimal.Decimal
+	Volume   decimal.Decimal
+}
+
+func (c *Candle) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw) < 6 {
+		return fmt.Errorf("unexpected candle length %d", len(raw))
+	}
+
+	var openTime int64
+	fields := []any{&openTime, &c.Low, &c.High, &c.Open, &c.Close, &c.Volume}
+	for i, field := range fields {
+		if err := json.Unmarshal(raw[i], field); err != nil {
+			return fmt.Errorf("failed to unmarshal candle field %d: %w", i, err)
+		}
+	}
+	c.OpenTime = time.Unix(openTime, 0).UTC()
+	return nil
+}
+
+type CandleParams struct {
+	// ProductID is the coinbase product, e.g. BTC-USD
+	ProductID   string
+	Granularity time.Duration
+	// Start and End limit open times of the candles
+	Start time.Time
+	End   time.Time
+}
+
+var granularities = map[time.Duration]struct{}{
+	time.Minute:      {},
+	5 * time.Minute:  {},
+	15 * time.Minute: {},
+	time.Hour:        {},
+	6 * time.Hour:    {},
+	24 * time.Hour:   {},
+}
+
+// SupportedGranularity reports whether coinbase serves candles of the duration
+func SupportedGranularity(d time.Duration) bool {
+	_, ok := granularities[d]
+	return ok
+}
+
+// GetCandles returns candles ordered by open time, the range is split into pages coinbase accepts
+func (c *Client) GetCandles(params CandleParams) ([]Candle, error) {
+	if !SupportedGranularity(params.Granularity) {
+		return nil, fmt.Errorf("unsupported candle granularity %s", params.Granularity)
+	}
+	var candles []Candle
+	page := params.Granularity * (maxCandlesPerRequest - 1)
+	for start := params.Start.Truncate(params.Granularity); !start.After(params.End); start = start.Add(page + params.Granularity) {
+		end := start.Add(page)
+		if end.After(params.End) {
+			end = params.End
+		}
+		fetched, err := c.getCandlesPage(params, start, end)
+		if err != nil {
+			return nil, err
+		}
+		candles = append(candles, fetched...)
+	}
+	sort.Slice(candles, func(i, j int) bool {
+		return candles[i].OpenTime.Before(candles[j].OpenTime)
+	})
+	return candles, nil
+}
+
+func (c *Client) getCandlesPage(params CandleParams, start time.Time, end time.Time) ([]Candle, error) {
+	var response []Candle
+	resp, err := c.R().
+		SetPathParam("product", params.ProductID).
+		SetQueryParam("granularity", fmt.Sprint(int64(params.Granularity.Seconds()))).
+		SetQueryParam("start", start.UTC().Format(time.RFC3339)).
+		SetQueryParam("end", end.UTC().Format(time.RFC3339)).
+		Get("/products/{product}/candles")
+	if err != nil {
+		c.logger.Error("Failed to get candles", zap.String("product", params.ProductID), zap.Error(err))
+		return nil, err
+	}
+	err = decodeResponse(resp, &response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
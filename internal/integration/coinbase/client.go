@@ -0,0 +1,146 @@
+package coinbase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/common/log"
+	"github.com/zheka156/market_data/internal/common/pacer"
+	"github.com/zheka156/market_data/internal/config"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultURL               = "https://api.exchange.coinbase.com"
+	defaultMaxRetries        = 3
+	defaultRequestsPerSecond = 8
+)
+
+type Coinbase interface {
+	GetTicker(productID string) (*Ticker, error)
+	GetProducts() ([]Product, error)
+	GetCandles(params CandleParams) ([]Candle, error)
+	Ping() error
+}
+
+type Client struct {
+	*resty.Client
+	logger *zap.Logger
+}
+
+func NewClient(logger *zap.Logger, conf *config.Config) *Client {
+	baseURL := conf.Coinbase.URL
+	if baseURL == "" {
+		baseURL = defaultURL
+	}
+	maxRetries := conf.Coinbase.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultMaxRetries
+	}
+	perSecond := conf.Coinbase.RequestsPerSecond
+	if perSecond <= 0 {
+		perSecond = defaultRequestsPerSecond
+	}
+
+	c := resty.New()
+	c.SetTransport(&log.LoggingRoundTripper{
+		Proxied: http.DefaultTransport,
+		Logger:  logger,
+	})
+	c.SetTimeout(30 * time.Second)
+	c.SetHeader("Content-Type", "application/json")
+	c.SetBaseURL(baseURL)
+
+	// coinbase limits public endpoints per second
+	p := pacer.New(perSecond)
+	c.OnBeforeRequest(func(client *resty.Client, request *resty.Request) error {
+		return p.Wait(request.Context())
+	})
+	c.SetRetryCount(maxRetries)
+	c.AddRetryCondition(func(response *resty.Response, err error) bool {
+		if err != nil {
+			return !errors.Is(err, context.Canceled)
+		}
+		status := response.StatusCode()
+		return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+	})
+	c.AddRetryHook(func(response *resty.Response, err error) {
+		fields := []zap.Field{zap.Error(err)}
+		if response != nil {
+			fields = append(fields, zap.Int("status", response.StatusCode()), zap.String("url", response.Request.URL))
+		}
+		logger.Warn("Retrying coinbase request", fields...)
+	})
+
+	return &Client{c, logger}
+}
+
+type Ticker struct {
+	Price  decimal.Decimal `json:"price"`
+	Bid    decimal.Decimal `json:"bid"`
+	Ask    decimal.Decimal `json:"ask"`
+	Volume decimal.Decimal `json:"volume"`
+	// Time is the time of the last trade
+	Time time.Time `json:"time"`
+}
+
+func (c *Client) GetTicker(productID string) (*Ticker, error) {
+	var response Ticker
+	resp, err := c.R().
+		SetPathParam("product", productID).
+		Get("/products/{product}/ticker")
+	if err != nil {
+		c.logger.Error("Failed to get ticker", zap.String("product", productID), zap.Error(err))
+		return nil, err
+	}
+	err = decodeResponse(resp, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+type Product struct {
+	ID              string `json:"id"`
+	BaseCurrency    string `json:"base_currency"`
+	QuoteCurrency   string `json:"quote_currency"`
+	Status          string `json:"status"`
+	TradingDisabled bool   `json:"trading_disabled"`
+}
+
+const ProductStatusOnline = "online"
+
+func (c *Client) GetProducts() ([]Product, error) {
+	var response []Product
+	resp, err := c.R().Get("/products")
+	if err != nil {
+		c.logger.Error("Failed to get products", zap.Error(err))
+		return nil, err
+	}
+	err = decodeResponse(resp, &response)
+	if err != nil {
+		c.logger.Error("Failed to decode response", zap.Error(err))
+		return nil, err
+	}
+	return response, nil
+}
+
+func (c *Client) Ping() error {
+	resp, err := c.R().Get("/time")
+	if err != nil {
+		return err
+	}
+	var response struct {
+		ISO string `json:"iso"`
+	}
+	return decodeResponse(resp, &response)
+}
+
+// ProductID returns the coinbase product name, e.g. BTC-USD
+func ProductID(base string, quote string) string {
+	return base + "-" + quote
+}
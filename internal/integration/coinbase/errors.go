@@ -0,0 +1,52 @@
+package coinbase
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-resty/resty/v2"
+)
+
+var (
+	ErrNotFound    = errors.New("product not found")
+	ErrRateLimited = errors.New("rate limited by coinbase")
+)
+
+// APIError is the error payload coinbase sends with unsuccessful responses.
+// Use errors.Is with ErrNotFound and ErrRateLimited to check the kind.
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Message    string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("coinbase error (http %d): %s", e.StatusCode, e.Message)
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// decodeResponse checks the status and unmarshals the body into v,
+// coinbase error payloads are returned as *APIError
+func decodeResponse(response *resty.Response, v any) error {
+	if response.IsError() {
+		apiErr := &APIError{StatusCode: response.StatusCode()}
+		if err := json.Unmarshal(response.Body(), apiErr); err != nil || apiErr.Message == "" {
+			apiErr.Message = response.Status()
+		}
+		return apiErr
+	}
+	if err := json.Unmarshal(response.Body(), v); err != nil {
+		return fmt.Errorf("failed to decode coinbase response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package coinbase
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/zheka156/market_data/internal/config"
+	"github.com/zheka156/market_data/internal/provider"
+	"go.uber.org/zap"
+)
+
+const (
+	ProviderName = "coinbase"
+	// coinbase has no batch ticker endpoint, products are requested in parallel
+	maxParallelRequests = 4
+)
+
+// Provider serves crypto prices from coinbase exchange products
+type Provider struct {
+	client Coinbase
+	log    *zap.Logger
+	// products and quotes map assets to coinbase names, see config.Coinbase
+	products map[string]string
+	quotes   map[string]string
+}
+
+func NewProvider(logger *zap.Logger, client Coinbase, conf config.Coinbase) *Provider {
+	return &Provider{
+		client:   client,
+		log:      logger,
+		products: conf.Products,
+		quotes:   conf.QuoteSubstitutes,
+	}
+}
+
+func (p *Provider) Name() string {
+	return ProviderName
+}
+
+// ProductID returns the coinbase product of the asset, configured products take precedence
+// over the base and substituted quote, e.g. BTC/USDT is BTC-USD
+func (p *Provider) ProductID(asset provider.Asset) string {
+	if product, ok := p.products[asset.String()]; ok {
+		return product
+	}
+	quote := asset.Quote
+	if substitute, ok := p.quotes[quote]; ok {
+		quote = substitute
+	}
+	return ProductID(asset.Symbol, quote)
+}
+
+func (p *Provider) LastPrice(asset provider.Asset) (*provider.Quote, error) {
+	ticker, err := p.client.GetTicker(p.ProductID(asset))
+	if err != nil {
+		return nil, unknownAsset(err)
+	}
+	if ticker.Time.IsZero() {
+		ticker.Time = time.Now().UTC()
+	}
+	return &provider.Quote{Asset: asset, Price: ticker.Price, Time: ticker.Time.UTC(), Source: ProviderName, Volume: ticker.Volume}, nil
+}
+
+// BatchLastPrice requests tickers of the assets in parallel, products coinbase doesn't list are returned as unknown
+func (p *Provider) BatchLastPrice(assets []provider.Asset) ([]provider.Quote, []provider.Asset, error) {
+	quotes := make([]*provider.Quote, len(assets))
+	errs := make([]error, len(assets))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxParallelRequests)
+	for i, asset := range assets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			quotes[i], errs[i] = p.LastPrice(asset)
+		}()
+	}
+	wg.Wait()
+
+	var fetched []provider.Quote
+	var unknown []provider.Asset
+	var failed []error
+	for i, asset := range assets {
+		switch {
+		case errors.Is(errs[i], provider.ErrUnknownAsset):
+			p.log.Warn("Product is not listed", zap.Stringer("asset", asset), zap.String("product", p.ProductID(asset)))
+			unknown = append(unknown, asset)
+		case errs[i] != nil:
+			failed = append(failed, fmt.Errorf("%s: %w", asset, errs[i]))
+		default:
+			fetched = append(fetched, *quotes[i])
+		}
+	}
+	if len(failed) != 0 {
+		return nil, nil, errors.Join(failed...)
+	}
+	return fetched, unknown, nil
+}
+
+func (p *Provider) History(asset provider.Asset, from time.Time, to time.Time, interval time.Duration) ([]provider.Candle, error) {
+	if !SupportedGranularity(interval) {
+		return nil, fmt.Errorf("interval %s: %w", interval, provider.ErrUnsupported)
+	}
+	candles, err := p.client.GetCandles(CandleParams{
+		ProductID:   p.ProductID(asset),
+		Granularity: interval,
+		Start:       from,
+		End:         to,
+	})
+	if err != nil {
+		return nil, unknownAsset(err)
+	}
+	result := make([]provider.Candle, 0, len(candles))
+	for _, c := range candles {
+		result = append(result, provider.Candle{
+			OpenTime: c.OpenTime,
+			Open:     c.Open,
+			High:     c.High,
+			Low:      c.Low,
+			Close:    c.Close,
+			Volume:   c.Volume,
+		})
+	}
+	return result, nil
+}
+
+// SupportedSymbols returns online products in coinbase names, quotes are not substituted back
+func (p *Provider) SupportedSymbols() ([]provider.Asset, error) {
+	products, err := p.client.GetProducts()
+	if err != nil {
+		return nil, err
+	}
+	assets := make([]provider.Asset, 0, len(products))
+	for _, product := range products {
+		if product.Status != ProductStatusOnline || product.TradingDisabled {
+			continue
+		}
+		assets = append(assets, provider.Asset{Symbol: product.BaseCurrency, Quote: product.QuoteCurrency, Class: provider.Crypto})
+	}
+	return assets, nil
+}
+
+func (p *Provider) Health() error {
+	return p.client.Ping()
+}
+
+// unknownAsset marks not found errors so callers don't depend on coinbase errors
+func unknownAsset(err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("%w: %w", provider.ErrUnknownAsset, err)
+	}
+	return err
+}
@@ -0,0 +1,130 @@
+package coinbase_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/config"
+	"github.com/zheka156/market_data/internal/integration/coinbase"
+	"github.com/zheka156/market_data/internal/provider"
+	"github.com/zheka156/market_data/internal/provider/providertest"
+	"go.uber.org/zap"
+)
+
+var testConf = config.Coinbase{
+	MaxRetries:        1,
+	RequestsPerSecond: 1000,
+	QuoteSubstitutes:  map[string]string{"USDT": "USD"},
+	Products:          map[string]string{"POL/USDT": "MATIC-USD"},
+}
+
+// newTestProvider serves tickers of the listed products and hourly candles of any product
+func newTestProvider(t *testing.T, prices map[string]string) (*coinbase.Provider, *atomic.Int32) {
+	t.Helper()
+	var candleRequests atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /products/{product}/ticker", func(w http.ResponseWriter, r *http.Request) {
+		price, ok := prices[r.PathValue("product")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"NotFound"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"price": price, "time": "2026-10-19T12:00:00.5Z"})
+	})
+	mux.HandleFunc("GET /products/{product}/candles", func(w http.ResponseWriter, r *http.Request) {
+		candleRequests.Add(1)
+		granularity, _ := strconv.Atoi(r.URL.Query().Get("granularity"))
+		start, _ := time.Parse(time.RFC3339, r.URL.Query().Get("start"))
+		end, _ := time.Parse(time.RFC3339, r.URL.Query().Get("end"))
+		// newest first like coinbase
+		var candles [][]float64
+		for open := end; !open.Before(start); open = open.Add(-time.Duration(granularity) * time.Second) {
+			candles = append(candles, []float64{float64(open.Unix()), 1, 3, 2, 2.5, 10})
+		}
+		json.NewEncoder(w).Encode(candles)
+	})
+	server := providertest.NewServer(t, mux)
+
+	conf := testConf
+	conf.URL = server.URL
+	client := coinbase.NewClient(zap.NewNop(), &config.Config{Coinbase: conf})
+	return coinbase.NewProvider(zap.NewNop(), client, conf), &candleRequests
+}
+
+func TestProductID(t *testing.T) {
+	p, _ := newTestProvider(t, nil)
+	cases := map[provider.Asset]string{
+		providertest.Crypto("BTC", "USDT"): "BTC-USD",
+		providertest.Crypto("ETH", "BTC"):  "ETH-BTC",
+		providertest.Crypto("POL", "USDT"): "MATIC-USD",
+	}
+	for asset, want := range cases {
+		if got := p.ProductID(asset); got != want {
+			t.Errorf("expected %s for %s, got %s", want, asset, got)
+		}
+	}
+}
+
+func TestBatchLastPrice(t *testing.T) {
+	p, _ := newTestProvider(t, map[string]string{"BTC-USD": "65000.5", "MATIC-USD": "0.4"})
+
+	quotes, unknown, err := p.BatchLastPrice([]provider.Asset{providertest.Crypto("BTC", "USDT"), providertest.Crypto("DEAD", "USDT"), providertest.Crypto("POL", "USDT")})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(quotes) != 2 || quotes[0].Asset.Symbol != "BTC" || quotes[1].Asset.Symbol != "POL" {
+		t.Fatalf("unexpected quotes %+v", quotes)
+	}
+	if !quotes[0].Price.Equal(decimal.RequireFromString("65000.5")) || quotes[0].Source != coinbase.ProviderName {
+		t.Errorf("unexpected BTC quote %+v", quotes[0])
+	}
+	if quotes[0].Time.Unix() != time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC).Unix() {
+		t.Errorf("expected the trade time, got %s", quotes[0].Time)
+	}
+	if len(unknown) != 1 || unknown[0].Symbol != "DEAD" {
+		t.Errorf("expected DEAD to be unknown, got %v", unknown)
+	}
+}
+
+func TestLastPriceUnknownProduct(t *testing.T) {
+	p, _ := newTestProvider(t, nil)
+
+	_, err := p.LastPrice(providertest.Crypto("DEAD", "USDT"))
+	if !errors.Is(err, provider.ErrUnknownAsset) || !errors.Is(err, coinbase.ErrNotFound) {
+		t.Fatalf("expected unknown asset error, got %v", err)
+	}
+}
+
+func TestHistoryPaginates(t *testing.T) {
+	p, requests := newTestProvider(t, nil)
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(499 * time.Hour)
+
+	candles, err := p.History(providertest.Crypto("BTC", "USDT"), from, to, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(candles) != 500 {
+		t.Fatalf("expected 500 candles, got %d", len(candles))
+	}
+	if !candles[0].OpenTime.Equal(from) || !candles[499].OpenTime.Equal(to) {
+		t.Errorf("expected candles ordered by open time, got %s..%s", candles[0].OpenTime, candles[499].OpenTime)
+	}
+	if !candles[0].Open.Equal(decimal.NewFromInt(2)) || !candles[0].Low.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("unexpected candle %+v", candles[0])
+	}
+	if requests.Load() != 2 {
+		t.Errorf("expected 2 pages, got %d requests", requests.Load())
+	}
+
+	if _, err := p.History(providertest.Crypto("BTC", "USDT"), from, to, 2*time.Hour); !errors.Is(err, provider.ErrUnsupported) {
+		t.Errorf("expected unsupported interval error, got %v", err)
+	}
+}
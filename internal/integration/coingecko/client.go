@@ -0,0 +1,200 @@
+package coingecko
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/common/log"
+	"github.com/zheka156/market_data/internal/common/pacer"
+	"github.com/zheka156/market_data/internal/config"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultURL        = "https://api.coingecko.com/api/v3"
+	defaultMaxRetries = 3
+	// the public api allows about 30 requests a minute
+	defaultRequestInterval = 2 * time.Second
+)
+
+type CoinGecko interface {
+	GetSimplePrice(ids []string, vsCurrency string) ([]SimplePrice, error)
+	GetMarketChartRange(id string, vsCurrency string, from time.Time, to time.Time) ([]ChartPoint, error)
+	GetCoinsList() ([]Coin, error)
+	Ping() error
+}
+
+type Client struct {
+	*resty.Client
+	logger *zap.Logger
+}
+
+func NewClient(logger *zap.Logger, conf *config.Config) *Client {
+	baseURL := conf.CoinGecko.URL
+	if baseURL == "" {
+		baseURL = defaultURL
+	}
+	maxRetries := conf.CoinGecko.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultMaxRetries
+	}
+	interval := conf.CoinGecko.RequestInterval
+	if interval <= 0 {
+		interval = defaultRequestInterval
+	}
+
+	c := resty.New()
+	c.SetTransport(&log.LoggingRoundTripper{
+		Proxied: http.DefaultTransport,
+		Logger:  logger,
+	})
+	c.SetTimeout(30 * time.Second)
+	c.SetHeader("Accept", "application/json")
+	c.SetBaseURL(baseURL)
+	if key := os.Getenv("COINGECKO_API_KEY"); key != "" {
+		c.SetHeader("x-cg-demo-api-key", key)
+	}
+
+	p := pacer.Every(interval)
+	c.OnBeforeRequest(func(client *resty.Client, request *resty.Request) error {
+		return p.Wait(request.Context())
+	})
+	c.SetRetryCount(maxRetries)
+	c.AddRetryCondition(func(response *resty.Response, err error) bool {
+		if err != nil {
+			return !errors.Is(err, context.Canceled)
+		}
+		status := response.StatusCode()
+		return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+	})
+	c.AddRetryHook(func(response *resty.Response, err error) {
+		fields := []zap.Field{zap.Error(err)}
+		if response != nil {
+			fields = append(fields, zap.Int("status", response.StatusCode()), zap.String("url", response.Request.URL))
+		}
+		logger.Warn("Retrying coingecko request", fields...)
+	})
+
+	return &Client{c, logger}
+}
+
+// SimplePrice is the price of a coin in the requested currency
+type SimplePrice struct {
+	ID        string
+	Price     decimal.Decimal
+	UpdatedAt time.Time
+}
+
+// GetSimplePrice returns prices of the coin IDs, unknown IDs are missing from the result
+func (c *Client) GetSimplePrice(ids []string, vsCurrency string) ([]SimplePrice, error) {
+	var response map[string]map[string]decimal.Decimal
+	vsCurrency = strings.ToLower(vsCurrency)
+	resp, err := c.R().
+		SetQueryParam("ids", strings.Join(ids, ",")).
+		SetQueryParam("vs_currencies", vsCurrency).
+		SetQueryParam("include_last_updated_at", "true").
+		SetQueryParam("precision", "full").
+		Get("/simple/price")
+	if err != nil {
+		c.logger.Error("Failed to get simple price", zap.Error(err))
+		return nil, err
+	}
+	err = decodeResponse(resp, &response)
+	if err != nil {
+		c.logger.Error("Failed to decode response", zap.Error(err))
+		return nil, err
+	}
+
+	prices := make([]SimplePrice, 0, len(response))
+	for _, id := range ids {
+		values, ok := response[id]
+		if !ok {
+			continue
+		}
+		price, ok := values[vsCurrency]
+		if !ok {
+			continue
+		}
+		updatedAt := time.Now().UTC()
+		if ts, ok := values["last_updated_at"]; ok {
+			updatedAt = time.Unix(ts.IntPart(), 0).UTC()
+		}
+		prices = append(prices, SimplePrice{ID: id, Price: price, UpdatedAt: updatedAt})
+	}
+	return prices, nil
+}
+
+// ChartPoint is a price sample of the market chart
+type ChartPoint struct {
+	Time  time.Time
+	Price decimal.Decimal
+}
+
+// GetMarketChartRange returns price samples in [from, to]. Coingecko picks the granularity:
+// 5 minutes within a day, hourly within 90 days and daily for longer ranges.
+func (c *Client) GetMarketChartRange(id string, vsCurrency string, from time.Time, to time.Time) ([]ChartPoint, error) {
+	var response struct {
+		Prices [][2]decimal.Decimal `json:"prices"`
+	}
+	resp, err := c.R().
+		SetPathParam("id", id).
+		SetQueryParam("vs_currency", strings.ToLower(vsCurrency)).
+		SetQueryParam("from", strconv.FormatInt(from.Unix(), 10)).
+		SetQueryParam("to", strconv.FormatInt(to.Unix(), 10)).
+		SetQueryParam("precision", "full").
+		Get("/coins/{id}/market_chart/range")
+	if err != nil {
+		c.logger.Error("Failed to get market chart", zap.String("id", id), zap.Error(err))
+		return nil, err
+	}
+	err = decodeResponse(resp, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]ChartPoint, 0, len(response.Prices))
+	for _, sample := range response.Prices {
+		points = append(points, ChartPoint{Time: time.UnixMilli(sample[0].IntPart()).UTC(), Price: sample[1]})
+	}
+	return points, nil
+}
+
+// Coin is a coingecko listing, Symbol is lower case and shared by unrelated coins
+type Coin struct {
+	ID     string `json:"id"`
+	Symbol string `json:"symbol"`
+	Name   string `json:"name"`
+}
+
+func (c *Client) GetCoinsList() ([]Coin, error) {
+	var response []Coin
+	resp, err := c.R().Get("/coins/list")
+	if err != nil {
+		c.logger.Error("Failed to get coins list", zap.Error(err))
+		return nil, err
+	}
+	err = decodeResponse(resp, &response)
+	if err != nil {
+		c.logger.Error("Failed to decode response", zap.Error(err))
+		return nil, err
+	}
+	return response, nil
+}
+
+func (c *Client) Ping() error {
+	resp, err := c.R().Get("/ping")
+	if err != nil {
+		return err
+	}
+	var response struct {
+		GeckoSays string `json:"gecko_says"`
+	}
+	return decodeResponse(resp, &response)
+}
@@ -0,0 +1,65 @@
+package coingecko
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-resty/resty/v2"
+)
+
+var (
+	ErrNotFound    = errors.New("coin not found")
+	ErrRateLimited = errors.New("rate limited by coingecko")
+)
+
+// APIError is an unsuccessful coingecko response.
+// Use errors.Is with ErrNotFound and ErrRateLimited to check the kind.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("coingecko error (http %d): %s", e.StatusCode, e.Message)
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// errorPayload covers both error formats coingecko sends
+type errorPayload struct {
+	Error  string `json:"error"`
+	Status struct {
+		ErrorMessage string `json:"error_message"`
+	} `json:"status"`
+}
+
+// decodeResponse checks the status and unmarshals the body into v,
+// coingecko errors are returned as *APIError
+func decodeResponse(response *resty.Response, v any) error {
+	if response.IsError() {
+		apiErr := &APIError{StatusCode: response.StatusCode(), Message: response.Status()}
+		var payload errorPayload
+		if json.Unmarshal(response.Body(), &payload) == nil {
+			if payload.Error != "" {
+				apiErr.Message = payload.Error
+			} else if payload.Status.ErrorMessage != "" {
+				apiErr.Message = payload.Status.ErrorMessage
+			}
+		}
+		return apiErr
+	}
+	if err := json.Unmarshal(response.Body(), v); err != nil {
+		return fmt.Errorf("failed to decode coingecko response: %w", err)
+	}
+	return nil
+}
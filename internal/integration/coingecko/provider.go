@@ -0,0 +1,261 @@
+package coingecko
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/config"
+	"github.com/zheka156/market_data/internal/provider"
+	"go.uber.org/zap"
+)
+
+const (
+	ProviderName       = "coingecko"
+	maxIDsPerRequest   = 100
+	idsRefreshInterval = 5 * time.Minute
+	// the coins list is large and changes slowly
+	listRefreshInterval = 24 * time.Hour
+)
+
+// IDs resolves our tickers to coingecko coin IDs. Symbols collide on coingecko,
+// so a coin is priced only once it is mapped to an ID.
+type IDs interface {
+	GetCoinGeckoIDs() (map[string]string, error)
+}
+
+// Provider serves prices of mapped coins from coingecko
+type Provider struct {
+	client CoinGecko
+	log    *zap.Logger
+	ids    IDs
+	quotes map[string]string
+
+	mu       sync.Mutex
+	mapping  map[string]string
+	mappedAt time.Time
+
+	// the coins list is kept apart so a slow download doesn't block prices
+	listMu       sync.Mutex
+	coins        []Coin
+	coinsFetched time.Time
+}
+
+func NewProvider(logger *zap.Logger, client CoinGecko, ids IDs, conf config.CoinGecko) *Provider {
+	return &Provider{
+		client: client,
+		log:    logger,
+		ids:    ids,
+		quotes: conf.QuoteSubstitutes,
+	}
+}
+
+func (p *Provider) Name() string {
+	return ProviderName
+}
+
+// loadIDs returns the ticker to ID mapping, it is reloaded periodically so new mappings are picked up
+func (p *Provider) loadIDs() (map[string]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.mapping != nil && time.Since(p.mappedAt) < idsRefreshInterval {
+		return p.mapping, nil
+	}
+	mapping, err := p.ids.GetCoinGeckoIDs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load coingecko ids: %w", err)
+	}
+	p.mapping = mapping
+	p.mappedAt = time.Now()
+	return mapping, nil
+}
+
+// Invalidate reloads the mapping on the next request
+func (p *Provider) Invalidate() {
+	p.mu.Lock()
+	p.mapping = nil
+	p.mu.Unlock()
+}
+
+// vsCurrency returns the coingecko currency of the quote, e.g. USDT is priced in USD
+func (p *Provider) vsCurrency(quote string) string {
+	if substitute, ok := p.quotes[quote]; ok {
+		quote = substitute
+	}
+	return strings.ToLower(quote)
+}
+
+func (p *Provider) LastPrice(asset provider.Asset) (*provider.Quote, error) {
+	quotes, unknown, err := p.BatchLastPrice([]provider.Asset{asset})
+	if err != nil {
+		return nil, err
+	}
+	if len(unknown) != 0 || len(quotes) == 0 {
+		return nil, fmt.Errorf("%s: %w", asset, provider.ErrUnknownAsset)
+	}
+	return &quotes[0], nil
+}
+
+// BatchLastPrice requests prices of mapped coins grouped by currency, coins without an ID are returned as unknown
+func (p *Provider) BatchLastPrice(assets []provider.Asset) ([]provider.Quote, []provider.Asset, error) {
+	mapping, err := p.loadIDs()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var unknown []provider.Asset
+	byCurrency := make(map[string][]provider.Asset)
+	var currencies []string
+	for _, asset := range assets {
+		if _, ok := mapping[asset.Symbol]; !ok {
+			unknown = append(unknown, asset)
+			continue
+		}
+		currency := p.vsCurrency(asset.Quote)
+		if _, ok := byCurrency[currency]; !ok {
+			currencies = append(currencies, currency)
+		}
+		byCurrency[currency] = append(byCurrency[currency], asset)
+	}
+
+	var quotes []provider.Quote
+	for _, currency := range currencies {
+		group := byCurrency[currency]
+		for i := 0; i < len(group); i += maxIDsPerRequest {
+			chunk := group[i:min(i+maxIDsPerRequest, len(group))]
+			ids := make([]string, 0, len(chunk))
+			for _, asset := range chunk {
+				ids = append(ids, mapping[asset.Symbol])
+			}
+			prices, err := p.client.GetSimplePrice(ids, currency)
+			if err != nil {
+				return nil, nil, err
+			}
+			byID := make(map[string]SimplePrice, len(prices))
+			for _, price := range prices {
+				byID[price.ID] = price
+			}
+			for _, asset := range chunk {
+				price, ok := byID[mapping[asset.Symbol]]
+				if !ok || !price.Price.IsPositive() {
+					p.log.Warn("No price returned for coin", zap.Stringer("asset", asset), zap.String("id", mapping[asset.Symbol]))
+					unknown = append(unknown, asset)
+					continue
+				}
+				quotes = append(quotes, provider.Quote{Asset: asset, Price: price.Price, Time: price.UpdatedAt, Source: ProviderName})
+			}
+		}
+	}
+	return quotes, unknown, nil
+}
+
+// History aggregates market chart samples into candles of the interval. Samples are hourly
+// for ranges up to 90 days and daily beyond, shorter intervals are not supported.
+// Coingecko has no per candle volume, it is left zero.
+func (p *Provider) History(asset provider.Asset, from time.Time, to time.Time, interval time.Duration) ([]provider.Candle, error) {
+	if interval < time.Hour {
+		return nil, fmt.Errorf("interval %s: %w", interval, provider.ErrUnsupported)
+	}
+	mapping, err := p.loadIDs()
+	if err != nil {
+		return nil, err
+	}
+	id, ok := mapping[asset.Symbol]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", asset, provider.ErrUnknownAsset)
+	}
+	points, err := p.client.GetMarketChartRange(id, p.vsCurrency(asset.Quote), from, to.Add(interval))
+	if errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("%w: %w", provider.ErrUnknownAsset, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var candles []provider.Candle
+	for _, point := range points {
+		open := point.Time.Truncate(interval)
+		if open.Before(from) || open.After(to) {
+			continue
+		}
+		if n := len(candles); n != 0 && candles[n-1].OpenTime.Equal(open) {
+			last := &candles[n-1]
+			last.High = decimal.Max(last.High, point.Price)
+			last.Low = decimal.Min(last.Low, point.Price)
+			last.Close = point.Price
+			continue
+		}
+		candles = append(candles, provider.Candle{
+			OpenTime: open,
+			Open:     point.Price,
+			High:     point.Price,
+			Low:      point.Price,
+			Close:    point.Price,
+		})
+	}
+	return candles, nil
+}
+
+// SupportedSymbols returns the mapped coins priced in USD
+func (p *Provider) SupportedSymbols() ([]provider.Asset, error) {
+	mapping, err := p.loadIDs()
+	if err != nil {
+		return nil, err
+	}
+	assets := make([]provider.Asset, 0, len(mapping))
+	for ticker := range mapping {
+		assets = append(assets, provider.Asset{Symbol: ticker, Quote: "USD", Class: provider.Crypto})
+	}
+	return assets, nil
+}
+
+func (p *Provider) Health() error {
+	return p.client.Ping()
+}
+
+// Candidates returns coingecko coins listed under the symbol, more than one means the symbol collides
+func (p *Provider) Candidates(symbol string) ([]Coin, error) {
+	coins, err := p.listCoins()
+	if err != nil {
+		return nil, err
+	}
+	var candidates []Coin
+	for _, coin := range coins {
+		if strings.EqualFold(coin.Symbol, symbol) {
+			candidates = append(candidates, coin)
+		}
+	}
+	return candidates, nil
+}
+
+// Coin returns the coingecko coin with the ID
+func (p *Provider) Coin(id string) (*Coin, error) {
+	coins, err := p.listCoins()
+	if err != nil {
+		return nil, err
+	}
+	for _, coin := range coins {
+		if coin.ID == id {
+			return &coin, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: %w", id, ErrNotFound)
+}
+
+func (p *Provider) listCoins() ([]Coin, error) {
+	p.listMu.Lock()
+	defer p.listMu.Unlock()
+	if p.coins != nil && time.Since(p.coinsFetched) < listRefreshInterval {
+		return p.coins, nil
+	}
+	coins, err := p.client.GetCoinsList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coingecko coins: %w", err)
+	}
+	p.coins = coins
+	p.coinsFetched = time.Now()
+	return coins, nil
+}
@@ -0,0 +1,143 @@
+package coingecko_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/config"
+	"github.com/zheka156/market_data/internal/integration/coingecko"
+	"github.com/zheka156/market_data/internal/provider"
+	"github.com/zheka156/market_data/internal/provider/providertest"
+	"go.uber.org/zap"
+)
+
+type staticIDs map[string]string
+
+func (ids staticIDs) GetCoinGeckoIDs() (map[string]string, error) {
+	return ids, nil
+}
+
+var usdPrices = map[string]string{
+	"pepe":         "0.00001234",
+	"the-open-net": "5.5",
+}
+
+func newTestProvider(t *testing.T, ids staticIDs) *coingecko.Provider {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /simple/price", func(w http.ResponseWriter, r *http.Request) {
+		currency := r.URL.Query().Get("vs_currencies")
+		result := map[string]map[string]json.Number{}
+		for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+			price, ok := usdPrices[id]
+			if !ok || currency != "usd" {
+				continue
+			}
+			result[id] = map[string]json.Number{currency: json.Number(price), "last_updated_at": "1760875200"}
+		}
+		json.NewEncoder(w).Encode(result)
+	})
+	mux.HandleFunc("GET /coins/{id}/market_chart/range", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := usdPrices[r.PathValue("id")]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"coin not found"}`))
+			return
+		}
+		from, _ := strconv.ParseInt(r.URL.Query().Get("from"), 10, 64)
+		// a sample every 20 minutes
+		var prices [][]float64
+		for i := int64(0); i < 6; i++ {
+			prices = append(prices, []float64{float64((from + i*1200) * 1000), float64(10 + i)})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"prices": prices})
+	})
+	mux.HandleFunc("GET /coins/list", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":"pepe","symbol":"pepe","name":"Pepe"},{"id":"pepe-2","symbol":"pepe","name":"Pepe 2.0"},{"id":"the-open-net","symbol":"ton","name":"Toncoin"}]`))
+	})
+	server := providertest.NewServer(t, mux)
+
+	conf := config.CoinGecko{
+		URL:              server.URL,
+		MaxRetries:       1,
+		RequestInterval:  time.Millisecond,
+		QuoteSubstitutes: map[string]string{"USDT": "USD"},
+	}
+	client := coingecko.NewClient(zap.NewNop(), &config.Config{CoinGecko: conf})
+	return coingecko.NewProvider(zap.NewNop(), client, ids, conf)
+}
+
+// pinned returns the asset pinned to coingecko like the coins mapped to it
+func pinned(symbol string, quote string) provider.Asset {
+	asset := providertest.Crypto(symbol, quote)
+	asset.Source = coingecko.ProviderName
+	return asset
+}
+
+func TestBatchLastPriceByMappedID(t *testing.T) {
+	p := newTestProvider(t, staticIDs{"PEPE": "pepe", "TON": "the-open-net", "GONE": "gone"})
+
+	quotes, unknown, err := p.BatchLastPrice([]provider.Asset{pinned("PEPE", "USDT"), pinned("TON", "USDT"), pinned("GONE", "USDT"), pinned("BTC", "USDT")})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(quotes) != 2 {
+		t.Fatalf("expected 2 quotes, got %+v", quotes)
+	}
+	pepe := quotes[0]
+	if pepe.Asset != pinned("PEPE", "USDT") || !pepe.Price.Equal(decimal.RequireFromString("0.00001234")) {
+		t.Errorf("unexpected PEPE quote %+v", pepe)
+	}
+	if pepe.Time.Unix() != 1760875200 || pepe.Source != coingecko.ProviderName {
+		t.Errorf("expected coingecko update time, got %+v", pepe)
+	}
+	// GONE is mapped but has no price, BTC is not mapped
+	if len(unknown) != 2 || unknown[0].Symbol != "BTC" || unknown[1].Symbol != "GONE" {
+		t.Errorf("expected BTC and GONE to be unknown, got %v", unknown)
+	}
+}
+
+func TestHistoryAggregatesSamples(t *testing.T) {
+	p := newTestProvider(t, staticIDs{"PEPE": "pepe", "GONE": "gone"})
+	from := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+	candles, err := p.History(pinned("PEPE", "USDT"), from, from.Add(time.Hour), time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(candles) != 2 {
+		t.Fatalf("expected 2 candles, got %+v", candles)
+	}
+	first := candles[0]
+	if !first.OpenTime.Equal(from) || !first.Open.Equal(decimal.NewFromInt(10)) || !first.High.Equal(decimal.NewFromInt(12)) || !first.Close.Equal(decimal.NewFromInt(12)) {
+		t.Errorf("unexpected candle %+v", first)
+	}
+
+	if _, err := p.History(pinned("GONE", "USDT"), from, from, time.Hour); !errors.Is(err, provider.ErrUnknownAsset) {
+		t.Errorf("expected unknown asset error, got %v", err)
+	}
+	if _, err := p.History(pinned("PEPE", "USDT"), from, from, time.Minute); !errors.Is(err, provider.ErrUnsupported) {
+		t.Errorf("expected unsupported interval error, got %v", err)
+	}
+}
+
+func TestCandidatesCollide(t *testing.T) {
+	p := newTestProvider(t, staticIDs{})
+
+	candidates, err := p.Candidates("PEPE")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(candidates) != 2 {
+		t.Errorf("expected both pepe coins, got %+v", candidates)
+	}
+	coin, err := p.Coin("the-open-net")
+	if err != nil || coin.Name != "Toncoin" {
+		t.Errorf("expected Toncoin, got %+v (%v)", coin, err)
+	}
+}
@@ -0,0 +1,139 @@
+// Package frankfurter serves ECB reference rates, published on working days around 16:00 CET
+package frankfurter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/common/log"
+	"github.com/zheka156/market_data/internal/config"
+	"github.com/zheka156/market_data/internal/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	SourceName        = "frankfurter"
+	defaultURL        = "https://api.frankfurter.app"
+	defaultMaxRetries = 3
+)
+
+// APIError is a response frankfurter rejected, e.g. a date before 1999
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("frankfurter error (http %d): %s", e.StatusCode, e.Message)
+}
+
+type Client struct {
+	*resty.Client
+	logger *zap.Logger
+}
+
+func NewClient(logger *zap.Logger, conf *config.Config) *Client {
+	baseURL := conf.FX.URL
+	if baseURL == "" {
+		baseURL = defaultURL
+	}
+	maxRetries := conf.FX.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultMaxRetries
+	}
+
+	c := resty.New()
+	c.SetTransport(&log.LoggingRoundTripper{
+		Proxied: http.DefaultTransport,
+		Logger:  logger,
+	})
+	c.SetTimeout(30 * time.Second)
+	c.SetHeader("Accept", "application/json")
+	c.SetBaseURL(baseURL)
+	c.SetRetryCount(maxRetries)
+	c.AddRetryCondition(func(response *resty.Response, err error) bool {
+		if err != nil {
+			return !errors.Is(err, context.Canceled)
+		}
+		status := response.StatusCode()
+		return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+	})
+
+	return &Client{c, logger}
+}
+
+func (c *Client) Name() string {
+	return SourceName
+}
+
+type ratesResponse struct {
+	Date  string                     `json:"date"`
+	Rates map[string]decimal.Decimal `json:"rates"`
+}
+
+// Rates returns the rates published on the date or the last working day before it
+func (c *Client) Rates(date time.Time) (*fx.Rates, error) {
+	path := "/latest"
+	if !date.IsZero() {
+		path = "/" + date.Format(time.DateOnly)
+	}
+	var response ratesResponse
+	if err := c.get(path, &response); err != nil {
+		c.logger.Error("Failed to get rates", zap.String("path", path), zap.Error(err))
+		return nil, err
+	}
+	published, err := time.Parse(time.DateOnly, response.Date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rates date %q: %w", response.Date, err)
+	}
+	return &fx.Rates{Date: published, Rates: response.Rates}, nil
+}
+
+type seriesResponse struct {
+	Rates map[string]map[string]decimal.Decimal `json:"rates"`
+}
+
+// Series returns rates of every working day in [from, to]
+func (c *Client) Series(from time.Time, to time.Time) ([]*fx.Rates, error) {
+	path := "/" + from.Format(time.DateOnly) + ".." + to.Format(time.DateOnly)
+	var response seriesResponse
+	if err := c.get(path, &response); err != nil {
+		c.logger.Error("Failed to get rates series", zap.String("path", path), zap.Error(err))
+		return nil, err
+	}
+	series := make([]*fx.Rates, 0, len(response.Rates))
+	for day, rates := range response.Rates {
+		published, err := time.Parse(time.DateOnly, day)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse rates date %q: %w", day, err)
+		}
+		series = append(series, &fx.Rates{Date: published, Rates: rates})
+	}
+	return series, nil
+}
+
+func (c *Client) get(path string, v any) error {
+	resp, err := c.R().SetQueryParam("base", fx.USD).Get(path)
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		var body struct {
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(resp.Body(), &body); err != nil || body.Message == "" {
+			body.Message = resp.Status()
+		}
+		return &APIError{StatusCode: resp.StatusCode(), Message: body.Message}
+	}
+	if err := json.Unmarshal(resp.Body(), v); err != nil {
+		return fmt.Errorf("failed to decode frankfurter response: %w", err)
+	}
+	return nil
+}
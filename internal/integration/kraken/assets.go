@@ -0,0 +1,51 @@
+package kraken
+
+import (
+	"strings"
+)
+
+// defaultAliases are kraken names of assets we list under other tickers
+var defaultAliases = map[string]string{
+	"XBT": "BTC",
+	"XDG": "DOGE",
+}
+
+// naming converts kraken asset names to our coin tickers
+type naming struct {
+	aliases map[string]string
+}
+
+func newNaming(aliases map[string]string) naming {
+	merged := make(map[string]string, len(defaultAliases)+len(aliases))
+	for kraken, ticker := range defaultAliases {
+		merged[kraken] = ticker
+	}
+	for kraken, ticker := range aliases {
+		merged[kraken] = ticker
+	}
+	return naming{aliases: merged}
+}
+
+// ticker returns our ticker of a short kraken asset name, e.g. XBT is BTC
+func (n naming) ticker(name string) string {
+	if ticker, ok := n.aliases[name]; ok {
+		return ticker
+	}
+	return name
+}
+
+// pairAssets returns the base and quote of a pair in our tickers. The short names of wsname are used,
+// pairs without it have legacy names where four letter assets carry an X (crypto) or Z (fiat) prefix, e.g. XXBTZUSD.
+func (n naming) pairAssets(pair AssetPair) (string, string) {
+	if base, quote, ok := strings.Cut(pair.WSName, "/"); ok {
+		return n.ticker(base), n.ticker(quote)
+	}
+	return n.ticker(stripLegacyPrefix(pair.Base)), n.ticker(stripLegacyPrefix(pair.Quote))
+}
+
+func stripLegacyPrefix(name string) string {
+	if len(name) == 4 && (name[0] == 'X' || name[0] == 'Z') {
+		return name[1:]
+	}
+	return name
+}
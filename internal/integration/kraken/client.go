@@ -0,0 +1,189 @@
+package kraken
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/common/log"
+	"github.com/zheka156/market_data/internal/common/pacer"
+	"github.com/zheka156/market_data/internal/config"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultURL               = "https://api.kraken.com"
+	defaultMaxRetries        = 3
+	defaultRequestsPerSecond = 1
+)
+
+type Kraken interface {
+	GetTickers(pairs []string) ([]Ticker, error)
+	GetAssetPairs() ([]AssetPair, error)
+	GetOHLC(pair string, interval time.Duration, since time.Time) ([]OHLC, error)
+	Ping() error
+}
+
+type Client struct {
+	*resty.Client
+	logger *zap.Logger
+}
+
+func NewClient(logger *zap.Logger, conf *config.Config) *Client {
+	baseURL := conf.Kraken.URL
+	if baseURL == "" {
+		baseURL = defaultURL
+	}
+	maxRetries := conf.Kraken.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultMaxRetries
+	}
+	perSecond := conf.Kraken.RequestsPerSecond
+	if perSecond <= 0 {
+		perSecond = defaultRequestsPerSecond
+	}
+
+	c := resty.New()
+	c.SetTransport(&log.LoggingRoundTripper{
+		Proxied: http.DefaultTransport,
+		Logger:  logger,
+	})
+	c.SetTimeout(30 * time.Second)
+	c.SetBaseURL(baseURL)
+
+	// kraken decreases a request counter once a second for public endpoints
+	p := pacer.New(perSecond)
+	c.OnBeforeRequest(func(client *resty.Client, request *resty.Request) error {
+		return p.Wait(request.Context())
+	})
+	c.SetRetryCount(maxRetries)
+	c.AddRetryCondition(func(response *resty.Response, err error) bool {
+		if err != nil {
+			return !errors.Is(err, context.Canceled)
+		}
+		// rate limit errors come with status 200
+		body := response.Body()
+		return response.StatusCode() >= http.StatusInternalServerError ||
+			bytes.Contains(body, []byte("Too many requests")) || bytes.Contains(body, []byte("Rate limit exceeded"))
+	})
+	c.AddRetryHook(func(response *resty.Response, err error) {
+		fields := []zap.Field{zap.Error(err)}
+		if response != nil {
+			fields = append(fields, zap.Int("status", response.StatusCode()), zap.String("url", response.Request.URL))
+		}
+		logger.Warn("Retrying kraken request", fields...)
+	})
+
+	return &Client{c, logger}
+}
+
+// Ticker is the last trade of a pair, Pair is the kraken pair name, e.g. XXBTZUSD
+type Ticker struct {
+	Pair string
+	Last decimal.Decimal
+	// Volume is the 24 hour volume in the base asset
+	Volume decimal.Decimal
+	// Time is the kraken server time of the response
+	Time time.Time
+}
+
+type tickerInfo struct {
+	// LastTrade is [price, lot volume]
+	LastTrade []decimal.Decimal `json:"c"`
+	// Volume is [today, last 24 hours]
+	Volume []decimal.Decimal `json:"v"`
+}
+
+// GetTickers returns tickers keyed by the kraken pair name, which may differ from the requested one.
+// Kraken fails the whole request if one pair is unknown.
+func (c *Client) GetTickers(pairs []string) ([]Ticker, error) {
+	var response map[string]tickerInfo
+	resp, err := c.R().
+		SetQueryParam("pair", strings.Join(pairs, ",")).
+		Get("/0/public/Ticker")
+	if err != nil {
+		c.logger.Error("Failed to get tickers", zap.Error(err))
+		return nil, err
+	}
+	err = decodeResponse(resp, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	serverTime, err := http.ParseTime(resp.Header().Get("Date"))
+	if err != nil {
+		serverTime = time.Now()
+	}
+	tickers := make([]Ticker, 0, len(response))
+	for pair, info := range response {
+		if len(info.LastTrade) == 0 {
+			continue
+		}
+		ticker := Ticker{Pair: pair, Last: info.LastTrade[0], Time: serverTime.UTC()}
+		if len(info.Volume) > 1 {
+			ticker.Volume = info.Volume[1]
+		}
+		tickers = append(tickers, ticker)
+	}
+	sort.Slice(tickers, func(i, j int) bool {
+		return tickers[i].Pair < tickers[j].Pair
+	})
+	return tickers, nil
+}
+
+// AssetPair is a tradable pair. Base and Quote are kraken asset names, e.g. XXBT and ZUSD,
+// WSName uses the short names, e.g. XBT/USD
+type AssetPair struct {
+	Name    string `json:"-"`
+	AltName string `json:"altname"`
+	WSName  string `json:"wsname"`
+	Base    string `json:"base"`
+	Quote   string `json:"quote"`
+	Status  string `json:"status"`
+}
+
+const PairStatusOnline = "online"
+
+func (c *Client) GetAssetPairs() ([]AssetPair, error) {
+	var response map[string]AssetPair
+	resp, err := c.R().Get("/0/public/AssetPairs")
+	if err != nil {
+		c.logger.Error("Failed to get asset pairs", zap.Error(err))
+		return nil, err
+	}
+	err = decodeResponse(resp, &response)
+	if err != nil {
+		c.logger.Error("Failed to decode response", zap.Error(err))
+		return nil, err
+	}
+	pairs := make([]AssetPair, 0, len(response))
+	for name, pair := range response {
+		pair.Name = name
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Name < pairs[j].Name
+	})
+	return pairs, nil
+}
+
+func (c *Client) Ping() error {
+	resp, err := c.R().Get("/0/public/Time")
+	if err != nil {
+		return err
+	}
+	var response struct {
+		UnixTime int64 `json:"unixtime"`
+	}
+	if err := decodeResponse(resp, &response); err != nil {
+		return fmt.Errorf("failed to get server time: %w", err)
+	}
+	return nil
+}
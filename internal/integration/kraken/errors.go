@@ -0,0 +1,73 @@
+package kraken
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+var (
+	ErrUnknownPair = errors.New("unknown asset pair")
+	ErrRateLimited = errors.New("rate limited by kraken")
+)
+
+// APIError holds the error messages kraken sends in the response body, usually with status 200.
+// Use errors.Is with ErrUnknownPair and ErrRateLimited to check the kind.
+type APIError struct {
+	StatusCode int
+	Messages   []string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("kraken error (http %d): %s", e.StatusCode, strings.Join(e.Messages, ", "))
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnknownPair:
+		return e.contains("Unknown asset pair")
+	case ErrRateLimited:
+		return e.contains("Too many requests") || e.contains("Rate limit exceeded")
+	}
+	return false
+}
+
+func (e *APIError) contains(message string) bool {
+	for _, m := range e.Messages {
+		if strings.Contains(m, message) {
+			return true
+		}
+	}
+	return false
+}
+
+// envelope wraps every kraken response
+type envelope struct {
+	Error  []string        `json:"error"`
+	Result json.RawMessage `json:"result"`
+}
+
+// decodeResponse unmarshals the result of the response into v,
+// kraken error messages are returned as *APIError
+func decodeResponse(response *resty.Response, v any) error {
+	var body envelope
+	if err := json.Unmarshal(response.Body(), &body); err != nil {
+		if response.IsError() {
+			return &APIError{StatusCode: response.StatusCode(), Messages: []string{response.Status()}}
+		}
+		return fmt.Errorf("failed to decode kraken response: %w", err)
+	}
+	if len(body.Error) != 0 {
+		return &APIError{StatusCode: response.StatusCode(), Messages: body.Error}
+	}
+	if response.IsError() {
+		return &APIError{StatusCode: response.StatusCode(), Messages: []string{response.Status()}}
+	}
+	if err := json.Unmarshal(body.Result, v); err != nil {
+		return fmt.Errorf("failed to decode kraken result: %w", err)
+	}
+	return nil
+}
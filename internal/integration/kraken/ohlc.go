@@ -0,0 +1,99 @@
+package kraken
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// OHLC is a single candlestick, kraken sends it as [time, open, high, low, close, vwap, volume, count]
+type OHLC struct {
+	OpenTime time.Time
+	Open     decimal.Decimal
+	High     decimal.Decimal
+	Low      decimal.Decimal
+	Close    decimal.Decimal
+	VWAP     decimal.Decimal
+	Volume   decimal.Decimal
+	Trades   int64
+}
+
+func (o *OHLC) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw) < 8 {
+		return fmt.Errorf("unexpected ohlc length %d", len(raw))
+	}
+
+	var openTime int64
+	fields := []any{&openTime, &o.Open, &o.High, &o.Low, &o.Close, &o.VWAP, &o.Volume, &o.Trades}
+	for i, field := range fields {
+		if err := json.Unmarshal(raw[i], field); err != nil {
+			return fmt.Errorf("failed to unmarshal ohlc field %d: %w", i, err)
+		}
+	}
+	o.OpenTime = time.Unix(openTime, 0).UTC()
+	return nil
+}
+
+var ohlcIntervals = map[time.Duration]struct{}{
+	time.Minute:         {},
+	5 * time.Minute:     {},
+	15 * time.Minute:    {},
+	30 * time.Minute:    {},
+	time.Hour:           {},
+	4 * time.Hour:       {},
+	24 * time.Hour:      {},
+	7 * 24 * time.Hour:  {},
+	15 * 24 * time.Hour: {},
+}
+
+// SupportedInterval reports whether kraken serves candles of the duration
+func SupportedInterval(d time.Duration) bool {
+	_, ok := ohlcIntervals[d]
+	return ok
+}
+
+// GetOHLC returns candles opened after since ordered by open time.
+// Kraken keeps only the last 720 candles of an interval, older ones are not returned.
+func (c *Client) GetOHLC(pair string, interval time.Duration, since time.Time) ([]OHLC, error) {
+	if !SupportedInterval(interval) {
+		return nil, fmt.Errorf("unsupported ohlc interval %s", interval)
+	}
+	req := c.R().
+		SetQueryParam("pair", pair).
+		SetQueryParam("interval", strconv.Itoa(int(interval.Minutes())))
+	if !since.IsZero() {
+		req.SetQueryParam("since", strconv.FormatInt(since.Unix(), 10))
+	}
+
+	var response map[string]json.RawMessage
+	resp, err := req.Get("/0/public/OHLC")
+	if err != nil {
+		c.logger.Error("Failed to get ohlc", zap.String("pair", pair), zap.Error(err))
+		return nil, err
+	}
+	err = decodeResponse(resp, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	// the result holds the candles under the kraken pair name and the "last" cursor
+	for key, raw := range response {
+		if key == "last" {
+			continue
+		}
+		var candles []OHLC
+		if err := json.Unmarshal(raw, &candles); err != nil {
+			return nil, fmt.Errorf("failed to decode ohlc of %s: %w", key, err)
+		}
+		return candles, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,246 @@
+package kraken
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/config"
+	"github.com/zheka156/market_data/internal/provider"
+	"go.uber.org/zap"
+)
+
+const (
+	ProviderName        = "kraken"
+	maxPairsPerRequest  = 20
+	pairRefreshInterval = 6 * time.Hour
+)
+
+// market is the kraken pair of an asset, inverted pairs are quoted the other way around, e.g. USDT/EUR for EUR/USDT
+type market struct {
+	pair     AssetPair
+	inverted bool
+}
+
+// Provider serves crypto and fiat prices from kraken pairs. Assets are matched to pairs in our tickers,
+// so quotes and stored rows never carry kraken names.
+type Provider struct {
+	client Kraken
+	log    *zap.Logger
+	naming naming
+	quotes map[string]string
+
+	mu       sync.Mutex
+	pairs    map[string]AssetPair
+	loadedAt time.Time
+}
+
+func NewProvider(logger *zap.Logger, client Kraken, conf config.Kraken) *Provider {
+	return &Provider{
+		client: client,
+		log:    logger,
+		naming: newNaming(conf.Aliases),
+		quotes: conf.QuoteSubstitutes,
+	}
+}
+
+func (p *Provider) Name() string {
+	return ProviderName
+}
+
+// loadPairs indexes online pairs by base and quote in our tickers, the index is refreshed periodically
+func (p *Provider) loadPairs() (map[string]AssetPair, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pairs != nil && time.Since(p.loadedAt) < pairRefreshInterval {
+		return p.pairs, nil
+	}
+
+	pairs, err := p.client.GetAssetPairs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kraken pairs: %w", err)
+	}
+	index := make(map[string]AssetPair, len(pairs))
+	for _, pair := range pairs {
+		if pair.Status != PairStatusOnline {
+			continue
+		}
+		base, quote := p.naming.pairAssets(pair)
+		index[base+"/"+quote] = pair
+	}
+	p.pairs = index
+	p.loadedAt = time.Now()
+	return index, nil
+}
+
+// resetPairs forces a reload, e.g. after kraken rejected a pair of the index
+func (p *Provider) resetPairs() {
+	p.mu.Lock()
+	p.pairs = nil
+	p.mu.Unlock()
+}
+
+// market finds the pair of the asset, the inverted pair and a substituted quote are tried next
+func (p *Provider) market(asset provider.Asset) (market, error) {
+	pairs, err := p.loadPairs()
+	if err != nil {
+		return market{}, err
+	}
+	quotes := []string{asset.Quote}
+	if substitute, ok := p.quotes[asset.Quote]; ok {
+		quotes = append(quotes, substitute)
+	}
+	for _, quote := range quotes {
+		if pair, ok := pairs[asset.Symbol+"/"+quote]; ok {
+			return market{pair: pair}, nil
+		}
+		if pair, ok := pairs[quote+"/"+asset.Symbol]; ok {
+			return market{pair: pair, inverted: true}, nil
+		}
+	}
+	return market{}, fmt.Errorf("%s: %w", asset, provider.ErrUnknownAsset)
+}
+
+func (p *Provider) LastPrice(asset provider.Asset) (*provider.Quote, error) {
+	quotes, unknown, err := p.BatchLastPrice([]provider.Asset{asset})
+	if err != nil {
+		return nil, err
+	}
+	if len(unknown) != 0 || len(quotes) == 0 {
+		return nil, fmt.Errorf("%s: %w", asset, provider.ErrUnknownAsset)
+	}
+	return &quotes[0], nil
+}
+
+// BatchLastPrice requests tickers of the matched pairs in batches, assets without a kraken pair are returned as unknown
+func (p *Provider) BatchLastPrice(assets []provider.Asset) ([]provider.Quote, []provider.Asset, error) {
+	var unknown []provider.Asset
+	markets := make(map[provider.Asset]market, len(assets))
+	var names []string
+	requested := make(map[string]bool)
+	for _, asset := range assets {
+		m, err := p.market(asset)
+		if errors.Is(err, provider.ErrUnknownAsset) {
+			unknown = append(unknown, asset)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		markets[asset] = m
+		if !requested[m.pair.Name] {
+			requested[m.pair.Name] = true
+			names = append(names, m.pair.Name)
+		}
+	}
+
+	tickers := make(map[string]Ticker, len(names))
+	for i := 0; i < len(names); i += maxPairsPerRequest {
+		end := min(i+maxPairsPerRequest, len(names))
+		fetched, err := p.client.GetTickers(names[i:end])
+		if err != nil {
+			if errors.Is(err, ErrUnknownPair) {
+				p.resetPairs()
+			}
+			return nil, nil, err
+		}
+		for _, ticker := range fetched {
+			tickers[ticker.Pair] = ticker
+		}
+	}
+
+	var quotes []provider.Quote
+	for _, asset := range assets {
+		m, ok := markets[asset]
+		if !ok {
+			continue
+		}
+		ticker, ok := tickers[m.pair.Name]
+		if !ok {
+			ticker, ok = tickers[m.pair.AltName]
+		}
+		if !ok || !ticker.Last.IsPositive() {
+			p.log.Warn("No ticker returned for pair", zap.Stringer("asset", asset), zap.String("pair", m.pair.Name))
+			unknown = append(unknown, asset)
+			continue
+		}
+		price, volume := ticker.Last, ticker.Volume
+		if m.inverted {
+			// the asset is the kraken quote, its volume is the traded value
+			price, volume = invert(price), volume.Mul(ticker.Last)
+		}
+		quotes = append(quotes, provider.Quote{Asset: asset, Price: price, Time: ticker.Time, Source: ProviderName, Volume: volume})
+	}
+	return quotes, unknown, nil
+}
+
+// History returns candles in [from, to]. Kraken keeps the last 720 candles of an interval,
+// older ones are missing from the result.
+func (p *Provider) History(asset provider.Asset, from time.Time, to time.Time, interval time.Duration) ([]provider.Candle, error) {
+	if !SupportedInterval(interval) {
+		return nil, fmt.Errorf("interval %s: %w", interval, provider.ErrUnsupported)
+	}
+	m, err := p.market(asset)
+	if err != nil {
+		return nil, err
+	}
+	ohlc, err := p.client.GetOHLC(m.pair.Name, interval, from.Add(-interval))
+	if err != nil {
+		return nil, err
+	}
+
+	candles := make([]provider.Candle, 0, len(ohlc))
+	for _, o := range ohlc {
+		if o.OpenTime.Before(from) || o.OpenTime.After(to) {
+			continue
+		}
+		candle := provider.Candle{
+			OpenTime: o.OpenTime,
+			Open:     o.Open,
+			High:     o.High,
+			Low:      o.Low,
+			Close:    o.Close,
+			Volume:   o.Volume,
+		}
+		if m.inverted {
+			// the low of the pair is the high of the inverted one, volume is in the quote of the pair
+			candle = provider.Candle{
+				OpenTime: o.OpenTime,
+				Open:     invert(o.Open),
+				High:     invert(o.Low),
+				Low:      invert(o.High),
+				Close:    invert(o.Close),
+				Volume:   o.Volume.Mul(o.VWAP),
+			}
+		}
+		candles = append(candles, candle)
+	}
+	return candles, nil
+}
+
+// SupportedSymbols returns online pairs in our tickers
+func (p *Provider) SupportedSymbols() ([]provider.Asset, error) {
+	pairs, err := p.loadPairs()
+	if err != nil {
+		return nil, err
+	}
+	assets := make([]provider.Asset, 0, len(pairs))
+	for _, pair := range pairs {
+		base, quote := p.naming.pairAssets(pair)
+		assets = append(assets, provider.Asset{Symbol: base, Quote: quote, Class: provider.Crypto})
+	}
+	return assets, nil
+}
+
+func (p *Provider) Health() error {
+	return p.client.Ping()
+}
+
+func invert(price decimal.Decimal) decimal.Decimal {
+	if price.IsZero() {
+		return price
+	}
+	return decimal.NewFromInt(1).Div(price)
+}
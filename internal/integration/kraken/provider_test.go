@@ -0,0 +1,156 @@
+package kraken_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/config"
+	"github.com/zheka156/market_data/internal/integration/kraken"
+	"github.com/zheka156/market_data/internal/provider"
+	"github.com/zheka156/market_data/internal/provider/providertest"
+	"go.uber.org/zap"
+)
+
+const assetPairs = `{"error":[],"result":{
+	"XXBTZUSD":{"altname":"XBTUSD","wsname":"XBT/USD","base":"XXBT","quote":"ZUSD","status":"online"},
+	"XETHZUSD":{"altname":"ETHUSD","base":"XETH","quote":"ZUSD","status":"online"},
+	"USDTEUR":{"altname":"USDTEUR","wsname":"USDT/EUR","base":"USDT","quote":"ZEUR","status":"online"},
+	"XXDGZGBP":{"altname":"XDGGBP","wsname":"XDG/GBP","base":"XXDG","quote":"ZGBP","status":"online"},
+	"LUNAUSD":{"altname":"LUNAUSD","wsname":"LUNA/USD","base":"LUNA","quote":"ZUSD","status":"delisted"}
+}}`
+
+var lastTrades = map[string]string{
+	"XXBTZUSD": "65000.1",
+	"XETHZUSD": "3200",
+	"USDTEUR":  "0.8",
+	"XXDGZGBP": "0.12",
+}
+
+func newTestProvider(t *testing.T) (*kraken.Provider, map[string]int) {
+	t.Helper()
+	requests := map[string]int{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /0/public/AssetPairs", func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		w.Write([]byte(assetPairs))
+	})
+	mux.HandleFunc("GET /0/public/Ticker", func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		result := map[string]any{}
+		for _, pair := range strings.Split(r.URL.Query().Get("pair"), ",") {
+			price, ok := lastTrades[pair]
+			if !ok {
+				w.Write([]byte(`{"error":["EQuery:Unknown asset pair"]}`))
+				return
+			}
+			result[pair] = map[string]any{"c": []string{price, "0.1"}}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"error": []string{}, "result": result})
+	})
+	mux.HandleFunc("GET /0/public/OHLC", func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		pair := r.URL.Query().Get("pair")
+		candles := [][]any{
+			{1767225600, "0.8", "1", "0.5", "0.8", "0.75", "100", 5},
+			{1767229200, "0.8", "0.8", "0.8", "0.8", "0.8", "10", 1},
+		}
+		json.NewEncoder(w).Encode(map[string]any{"error": []string{}, "result": map[string]any{pair: candles, "last": 1767229200}})
+	})
+	server := providertest.NewServer(t, mux)
+
+	conf := config.Kraken{
+		URL:               server.URL,
+		MaxRetries:        1,
+		RequestsPerSecond: 1000,
+		QuoteSubstitutes:  map[string]string{"USDT": "USD"},
+	}
+	client := kraken.NewClient(zap.NewNop(), &config.Config{Kraken: conf})
+	return kraken.NewProvider(zap.NewNop(), client, conf), requests
+}
+
+func TestBatchLastPriceMapsKrakenNames(t *testing.T) {
+	p, requests := newTestProvider(t)
+	assets := []provider.Asset{
+		providertest.Crypto("BTC", "USDT"),
+		providertest.Crypto("ETH", "USD"),
+		providertest.Crypto("EUR", "USDT"),
+		providertest.Crypto("DOGE", "GBP"),
+		providertest.Crypto("LUNA", "USD"),
+		providertest.Crypto("NOPE", "USDT"),
+	}
+
+	quotes, unknown, err := p.BatchLastPrice(assets)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	got := map[provider.Asset]decimal.Decimal{}
+	for _, quote := range quotes {
+		got[quote.Asset] = quote.Price
+		if quote.Source != kraken.ProviderName {
+			t.Errorf("unexpected source %s", quote.Source)
+		}
+	}
+	want := map[provider.Asset]string{
+		providertest.Crypto("BTC", "USDT"): "65000.1",
+		providertest.Crypto("ETH", "USD"):  "3200",
+		providertest.Crypto("EUR", "USDT"): "1.25",
+		providertest.Crypto("DOGE", "GBP"): "0.12",
+	}
+	for asset, price := range want {
+		if !got[asset].Equal(decimal.RequireFromString(price)) {
+			t.Errorf("expected %s for %s, got %s", price, asset, got[asset])
+		}
+	}
+	if len(unknown) != 2 || unknown[0].Symbol != "LUNA" || unknown[1].Symbol != "NOPE" {
+		t.Errorf("expected delisted and missing pairs to be unknown, got %v", unknown)
+	}
+	if requests["/0/public/Ticker"] != 1 {
+		t.Errorf("expected 1 ticker request, got %d", requests["/0/public/Ticker"])
+	}
+
+	// pairs are cached
+	if _, err := p.LastPrice(providertest.Crypto("BTC", "USD")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if requests["/0/public/AssetPairs"] != 1 {
+		t.Errorf("expected pairs loaded once, got %d requests", requests["/0/public/AssetPairs"])
+	}
+}
+
+func TestLastPriceUnknownAsset(t *testing.T) {
+	p, _ := newTestProvider(t)
+
+	_, err := p.LastPrice(providertest.Crypto("NOPE", "EUR"))
+	if !errors.Is(err, provider.ErrUnknownAsset) {
+		t.Fatalf("expected unknown asset error, got %v", err)
+	}
+}
+
+func TestHistoryInvertsPair(t *testing.T) {
+	p, _ := newTestProvider(t)
+	from := time.Unix(1767225600, 0).UTC()
+
+	candles, err := p.History(providertest.Crypto("EUR", "USDT"), from, from.Add(time.Hour), time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(candles) != 2 {
+		t.Fatalf("expected 2 candles, got %d", len(candles))
+	}
+	first := candles[0]
+	if !first.Open.Equal(decimal.RequireFromString("1.25")) || !first.High.Equal(decimal.NewFromInt(2)) || !first.Low.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("unexpected inverted candle %+v", first)
+	}
+	if !first.Volume.Equal(decimal.NewFromInt(75)) {
+		t.Errorf("expected volume in EUR, got %s", first.Volume)
+	}
+
+	if _, err := p.History(providertest.Crypto("BTC", "USD"), from, from, 2*time.Hour); !errors.Is(err, provider.ErrUnsupported) {
+		t.Errorf("expected unsupported interval error, got %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+// Package openerapi serves daily rates of open.er-api.com, it covers more currencies
+// than the ECB, e.g. RUB, but the free api has no history
+package openerapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/common/log"
+	"github.com/zheka156/market_data/internal/config"
+	"github.com/zheka156/market_data/internal/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	SourceName        = "open_er_api"
+	defaultURL        = "https://open.er-api.com/v6"
+	defaultMaxRetries = 3
+	resultSuccess     = "success"
+)
+
+// APIError is an error result, e.g. unsupported-code or a rate limit
+type APIError struct {
+	StatusCode int
+	Type       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("open er api error (http %d): %s", e.StatusCode, e.Type)
+}
+
+type Client struct {
+	*resty.Client
+	logger *zap.Logger
+}
+
+func NewClient(logger *zap.Logger, conf *config.Config) *Client {
+	baseURL := conf.FX.URL
+	if baseURL == "" {
+		baseURL = defaultURL
+	}
+	maxRetries := conf.FX.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultMaxRetries
+	}
+
+	c := resty.New()
+	c.SetTransport(&log.LoggingRoundTripper{
+		Proxied: http.DefaultTransport,
+		Logger:  logger,
+	})
+	c.SetTimeout(30 * time.Second)
+	c.SetHeader("Accept", "application/json")
+	c.SetBaseURL(baseURL)
+	c.SetRetryCount(maxRetries)
+	c.AddRetryCondition(func(response *resty.Response, err error) bool {
+		if err != nil {
+			return !errors.Is(err, context.Canceled)
+		}
+		status := response.StatusCode()
+		return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+	})
+
+	return &Client{c, logger}
+}
+
+func (c *Client) Name() string {
+	return SourceName
+}
+
+type latestResponse struct {
+	Result     string                     `json:"result"`
+	ErrorType  string                     `json:"error-type"`
+	LastUpdate int64                      `json:"time_last_update_unix"`
+	Rates      map[string]decimal.Decimal `json:"rates"`
+}
+
+// Rates returns the latest rates, the rates of past dates are not served
+func (c *Client) Rates(date time.Time) (*fx.Rates, error) {
+	if !date.IsZero() {
+		return nil, fmt.Errorf("%s: %w", date.Format(time.DateOnly), fx.ErrHistoryUnsupported)
+	}
+	var response latestResponse
+	resp, err := c.R().SetPathParam("base", fx.USD).Get("/latest/{base}")
+	if err != nil {
+		c.logger.Error("Failed to get latest rates", zap.Error(err))
+		return nil, err
+	}
+	if err := json.Unmarshal(resp.Body(), &response); err != nil {
+		if resp.IsError() {
+			return nil, &APIError{StatusCode: resp.StatusCode(), Type: resp.Status()}
+		}
+		return nil, fmt.Errorf("failed to decode open er api response: %w", err)
+	}
+	if response.Result != resultSuccess {
+		return nil, &APIError{StatusCode: resp.StatusCode(), Type: response.ErrorType}
+	}
+	return &fx.Rates{Date: time.Unix(response.LastUpdate, 0).UTC(), Rates: response.Rates}, nil
+}
@@ -2,6 +2,8 @@ package polygon
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"time"
@@ -9,9 +11,27 @@ import (
 	polygon "github.com/polygon-io/client-go/rest"
 	"github.com/polygon-io/client-go/rest/models"
 	"github.com/zheka156/market_data/internal/common/log"
+	"github.com/zheka156/market_data/internal/provider"
 	"go.uber.org/zap"
 )
 
+// Both errors are answers of polygon rather than failures, they match provider.ErrUnknownAsset
+var (
+	// ErrNoData is returned when polygon has no aggregates for the date, e.g. on market holidays
+	ErrNoData = fmt.Errorf("no data for the requested date: %w", provider.ErrUnknownAsset)
+	// ErrUnknownTicker is returned when polygon has no ticker with the symbol
+	ErrUnknownTicker = fmt.Errorf("unknown ticker: %w", provider.ErrUnknownAsset)
+)
+
+type Polygon interface {
+	GetPreviousClose(ticker string) (*models.Agg, error)
+	GetAggregates(params AggregatesParams) ([]models.Agg, error)
+	GetGroupedDaily(date time.Time) ([]models.Agg, error)
+	GetTickerDetails(ticker string) (*models.Ticker, error)
+	SearchTickers(query string, limit int) ([]models.Ticker, error)
+	Ping() error
+}
+
 type Client struct {
 	*polygon.Client
 	logger *zap.Logger
@@ -29,16 +49,111 @@ func NewClient(logger *zap.Logger) *Client {
 	return &Client{c, logger}
 }
 
-func (c *Client) GetLastDatePrices(ticker string) *models.GetDailyOpenCloseAggResponse {
-	yesterday := time.Now().AddDate(0, 0, -1)
-	params := &models.GetDailyOpenCloseAggParams{
-		Ticker: ticker,
-		Date:   models.Date(yesterday),
+// GetPreviousClose returns the bar of the last completed session
+func (c *Client) GetPreviousClose(ticker string) (*models.Agg, error) {
+	params := &models.GetPreviousCloseAggParams{Ticker: ticker}
+	resp, err := c.GetPreviousCloseAgg(context.Background(), params)
+	if err != nil {
+		c.logger.Error("failed to get previous close", zap.String("ticker", ticker), zap.Error(err))
+		return nil, err
+	}
+	if len(resp.Results) == 0 {
+		return nil, fmt.Errorf("previous close of %s: %w", ticker, ErrNoData)
 	}
+	return &resp.Results[0], nil
+}
+
+// AggregatesParams requests bars of Multiplier x Timespan with open times in [From, To]
+type AggregatesParams struct {
+	Ticker     string
+	Multiplier int
+	Timespan   models.Timespan
+	From       time.Time
+	To         time.Time
+}
+
+// GetAggregates returns adjusted bars of the range in ascending order, pages are followed
+func (c *Client) GetAggregates(params AggregatesParams) ([]models.Agg, error) {
+	listParams := models.ListAggsParams{
+		Ticker:     params.Ticker,
+		Multiplier: params.Multiplier,
+		Timespan:   params.Timespan,
+		From:       models.Millis(params.From),
+		To:         models.Millis(params.To),
+	}.WithOrder(models.Asc).WithAdjusted(true)
+
+	var aggs []models.Agg
+	it := c.ListAggs(context.Background(), listParams)
+	for it.Next() {
+		aggs = append(aggs, it.Item())
+	}
+	if err := it.Err(); err != nil {
+		c.logger.Error("failed to get aggregates", zap.String("ticker", params.Ticker), zap.Error(err))
+		return nil, err
+	}
+	return aggs, nil
+}
+
+// GetGroupedDaily returns adjusted daily bars of all US stocks for the session date in one request,
+// ErrNoData if the market was closed that day
+func (c *Client) GetGroupedDaily(date time.Time) ([]models.Agg, error) {
+	params := models.GetGroupedDailyAggsParams{
+		Locale:     models.US,
+		MarketType: models.Stocks,
+		Date:       models.Date(date),
+	}.WithAdjusted(true)
+
+	resp, err := c.GetGroupedDailyAggs(context.Background(), params)
+	if err != nil {
+		c.logger.Error("failed to get grouped daily bars", zap.Time("date", date), zap.Error(err))
+		return nil, err
+	}
+	if len(resp.Results) == 0 {
+		return nil, fmt.Errorf("grouped daily bars on %s: %w", date.Format(time.DateOnly), ErrNoData)
+	}
+	return resp.Results, nil
+}
+
+// GetTickerDetails returns the name, exchange and currency of the ticker
+func (c *Client) GetTickerDetails(ticker string) (*models.Ticker, error) {
+	params := &models.GetTickerDetailsParams{Ticker: ticker}
+	resp, err := c.Client.GetTickerDetails(context.Background(), params)
+	if err != nil {
+		var errResp *models.ErrorResponse
+		if errors.As(err, &errResp) && errResp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%s: %w", ticker, ErrUnknownTicker)
+		}
+		c.logger.Error("failed to get ticker details", zap.String("ticker", ticker), zap.Error(err))
+		return nil, err
+	}
+	return &resp.Results, nil
+}
+
+// SearchTickers returns active stock tickers whose symbol or name matches the query, at most limit of them
+func (c *Client) SearchTickers(query string, limit int) ([]models.Ticker, error) {
+	params := models.ListTickersParams{}.
+		WithSearch(query).
+		WithMarket(models.AssetStocks).
+		WithActive(true).
+		WithLimit(limit)
+
+	var tickers []models.Ticker
+	it := c.ListTickers(context.Background(), params)
+	// the iterator follows pages, the first one holds enough results
+	for len(tickers) < limit && it.Next() {
+		tickers = append(tickers, it.Item())
+	}
+	if err := it.Err(); err != nil {
+		c.logger.Error("failed to search tickers", zap.String("query", query), zap.Error(err))
+		return nil, err
+	}
+	return tickers, nil
+}
 
-	resp, err := c.GetDailyOpenCloseAgg(context.Background(), params, models.WithTrace(true))
+func (c *Client) Ping() error {
+	_, err := c.GetMarketStatus(context.Background())
 	if err != nil {
-		c.logger.Error("failed to get last date prices", zap.Error(err))
+		return fmt.Errorf("failed to get market status: %w", err)
 	}
-	return resp
+	return nil
 }
@@ -0,0 +1,317 @@
+package polygon
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/polygon-io/client-go/rest/models"
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/provider"
+	"go.uber.org/zap"
+)
+
+const (
+	ProviderName = "polygon"
+	// ticker details rarely change and cost a request of the small free tier quota
+	detailsCacheTTL = 24 * time.Hour
+	searchCacheTTL  = time.Hour
+	maxSearchLimit  = 50
+)
+
+// Provider serves stock prices and ticker details from polygon
+type Provider struct {
+	client Polygon
+	log    *zap.Logger
+
+	mu       sync.Mutex
+	details  map[string]cachedDetails
+	searches map[string]cachedSearch
+	// track runs polygon calls made outside the registry through its health tracking
+	track provider.Tracker
+}
+
+type cachedDetails struct {
+	details *TickerDetails
+	// err is ErrUnknownTicker for tickers polygon doesn't know, other errors are not cached
+	err       error
+	fetchedAt time.Time
+}
+
+type cachedSearch struct {
+	results   []TickerDetails
+	fetchedAt time.Time
+}
+
+func NewProvider(logger *zap.Logger, client Polygon) *Provider {
+	return &Provider{
+		client:   client,
+		log:      logger,
+		details:  make(map[string]cachedDetails),
+		searches: make(map[string]cachedSearch),
+		track: func(units int, fn func() error) error {
+			return fn()
+		},
+	}
+}
+
+// SetTracker makes calls the registry doesn't make, e.g. ticker details, count in the provider health
+func (p *Provider) SetTracker(track provider.Tracker) {
+	p.track = track
+}
+
+func (p *Provider) Name() string {
+	return ProviderName
+}
+
+// LastPrice returns the close of the last completed session
+func (p *Provider) LastPrice(asset provider.Asset) (*provider.Quote, error) {
+	bar, err := p.previousClose(asset.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	return &provider.Quote{
+		Asset:  asset,
+		Price:  bar.Close,
+		Time:   bar.OpenTime,
+		Source: ProviderName,
+		Volume: bar.Volume,
+	}, nil
+}
+
+// PreviousClose returns the daily bar of the last completed session
+func (p *Provider) PreviousClose(ticker string) (*provider.Candle, error) {
+	var bar *provider.Candle
+	err := p.track(1, func() (err error) {
+		bar, err = p.previousClose(ticker)
+		return err
+	})
+	return bar, err
+}
+
+func (p *Provider) previousClose(ticker string) (*provider.Candle, error) {
+	agg, err := p.client.GetPreviousClose(ticker)
+	if errors.Is(err, ErrNoData) {
+		return nil, fmt.Errorf("no previous session of %s: %w", ticker, provider.ErrUnknownAsset)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &provider.Candle{
+		OpenTime: sessionDate(agg.Timestamp),
+		Open:     decimal.NewFromFloat(agg.Open),
+		High:     decimal.NewFromFloat(agg.High),
+		Low:      decimal.NewFromFloat(agg.Low),
+		Close:    decimal.NewFromFloat(agg.Close),
+		Volume:   decimal.NewFromFloat(agg.Volume),
+	}, nil
+}
+
+// BatchLastPrice requests tickers one by one, polygon has no batch endpoint for daily bars
+func (p *Provider) BatchLastPrice(assets []provider.Asset) ([]provider.Quote, []provider.Asset, error) {
+	var quotes []provider.Quote
+	var unknown []provider.Asset
+	for _, asset := range assets {
+		quote, err := p.LastPrice(asset)
+		if errors.Is(err, provider.ErrUnknownAsset) {
+			unknown = append(unknown, asset)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		quotes = append(quotes, *quote)
+	}
+	return quotes, unknown, nil
+}
+
+// History returns bars with open times in [from, to] in one request. Intervals are whole
+// minutes, hours or days, daily bars open at the session date.
+func (p *Provider) History(asset provider.Asset, from time.Time, to time.Time, interval time.Duration) ([]provider.Candle, error) {
+	multiplier, timespan, ok := aggregateSpan(interval)
+	if !ok {
+		return nil, fmt.Errorf("interval %s: %w", interval, provider.ErrUnsupported)
+	}
+	aggs, err := p.client.GetAggregates(AggregatesParams{
+		Ticker:     asset.Symbol,
+		Multiplier: multiplier,
+		Timespan:   timespan,
+		From:       from,
+		// daily bars open at midnight Eastern time, after the UTC date they belong to
+		To: to.Add(interval - time.Millisecond),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	candles := make([]provider.Candle, 0, len(aggs))
+	for _, agg := range aggs {
+		open := time.Time(agg.Timestamp).UTC()
+		if timespan == models.Day {
+			open = sessionDate(agg.Timestamp)
+		}
+		if open.Before(from.UTC().Truncate(interval)) || open.After(to) {
+			continue
+		}
+		candles = append(candles, provider.Candle{
+			OpenTime: open,
+			Open:     decimal.NewFromFloat(agg.Open),
+			High:     decimal.NewFromFloat(agg.High),
+			Low:      decimal.NewFromFloat(agg.Low),
+			Close:    decimal.NewFromFloat(agg.Close),
+			Volume:   decimal.NewFromFloat(agg.Volume),
+		})
+	}
+	if len(candles) == 0 {
+		p.log.Debug("No stock sessions", zap.String("ticker", asset.Symbol), zap.Time("from", from), zap.Time("to", to))
+	}
+	return candles, nil
+}
+
+// GroupedDaily returns the bars of the tickers for the session date keyed by ticker, fetched in
+// one request for the whole market. Tickers without a bar are left out. ErrNoData is returned if
+// the market has no bars, it was closed or the session is not published yet.
+func (p *Provider) GroupedDaily(day time.Time, tickers []string) (map[string]provider.Candle, error) {
+	var aggs []models.Agg
+	err := p.track(len(tickers), func() (err error) {
+		aggs, err = p.client.GetGroupedDaily(day)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	candles := make(map[string]provider.Candle, len(tickers))
+	for _, agg := range aggs {
+		if !slices.Contains(tickers, agg.Ticker) {
+			continue
+		}
+		candles[agg.Ticker] = provider.Candle{
+			OpenTime: day.UTC().Truncate(24 * time.Hour),
+			Open:     decimal.NewFromFloat(agg.Open),
+			High:     decimal.NewFromFloat(agg.High),
+			Low:      decimal.NewFromFloat(agg.Low),
+			Close:    decimal.NewFromFloat(agg.Close),
+			Volume:   decimal.NewFromFloat(agg.Volume),
+		}
+	}
+	return candles, nil
+}
+
+// aggregateSpan splits the interval into a polygon multiplier and timespan
+func aggregateSpan(interval time.Duration) (int, models.Timespan, bool) {
+	switch {
+	case interval <= 0:
+		return 0, "", false
+	case interval%(24*time.Hour) == 0:
+		return int(interval / (24 * time.Hour)), models.Day, true
+	case interval%time.Hour == 0:
+		return int(interval / time.Hour), models.Hour, true
+	case interval%time.Minute == 0:
+		return int(interval / time.Minute), models.Minute, true
+	}
+	return 0, "", false
+}
+
+// sessionDate returns the UTC date of a daily bar, polygon opens them at midnight Eastern time
+func sessionDate(ts models.Millis) time.Time {
+	return time.Time(ts).UTC().Add(12 * time.Hour).Truncate(24 * time.Hour)
+}
+
+// SupportedSymbols is not served, polygon lists tens of thousands of tickers
+func (p *Provider) SupportedSymbols() ([]provider.Asset, error) {
+	return nil, provider.ErrUnsupported
+}
+
+func (p *Provider) Health() error {
+	return p.client.Ping()
+}
+
+// TickerDetails describes a stock ticker, Currency is the trading currency in lower case, e.g. usd
+type TickerDetails struct {
+	Ticker          string `json:"ticker"`
+	Name            string `json:"name"`
+	Market          string `json:"market"`
+	Type            string `json:"type"`
+	PrimaryExchange string `json:"primary_exchange"`
+	Currency        string `json:"currency"`
+	Active          bool   `json:"active"`
+}
+
+func newTickerDetails(ticker models.Ticker) TickerDetails {
+	return TickerDetails{
+		Ticker:          ticker.Ticker,
+		Name:            ticker.Name,
+		Market:          ticker.Market,
+		Type:            ticker.Type,
+		PrimaryExchange: ticker.PrimaryExchange,
+		Currency:        ticker.CurrencyName,
+		Active:          ticker.Active,
+	}
+}
+
+// Details returns the details of the ticker, ErrUnknownTicker if polygon doesn't know it.
+// Answers are cached including unknown tickers.
+func (p *Provider) Details(ticker string) (*TickerDetails, error) {
+	p.mu.Lock()
+	cached, ok := p.details[ticker]
+	p.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < detailsCacheTTL {
+		return cached.details, cached.err
+	}
+
+	var result *models.Ticker
+	err := p.track(1, func() (err error) {
+		result, err = p.client.GetTickerDetails(ticker)
+		return err
+	})
+	if err != nil && !errors.Is(err, ErrUnknownTicker) {
+		return nil, err
+	}
+	cached = cachedDetails{err: err, fetchedAt: time.Now()}
+	if err == nil {
+		details := newTickerDetails(*result)
+		cached.details = &details
+	}
+	p.mu.Lock()
+	p.details[ticker] = cached
+	p.mu.Unlock()
+	return cached.details, cached.err
+}
+
+// Search returns stock tickers matching the query by symbol or name, results are cached for an hour
+func (p *Provider) Search(query string, limit int) ([]TickerDetails, error) {
+	limit = min(max(limit, 1), maxSearchLimit)
+	key := fmt.Sprintf("%s|%d", strings.ToLower(query), limit)
+	p.mu.Lock()
+	cached, ok := p.searches[key]
+	p.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < searchCacheTTL {
+		return cached.results, nil
+	}
+
+	var tickers []models.Ticker
+	err := p.track(1, func() (err error) {
+		tickers, err = p.client.SearchTickers(query, limit)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	results := make([]TickerDetails, 0, len(tickers))
+	for _, ticker := range tickers {
+		results = append(results, newTickerDetails(ticker))
+	}
+	p.mu.Lock()
+	for cachedKey, cached := range p.searches {
+		if time.Since(cached.fetchedAt) >= searchCacheTTL {
+			delete(p.searches, cachedKey)
+		}
+	}
+	p.searches[key] = cachedSearch{results: results, fetchedAt: time.Now()}
+	p.mu.Unlock()
+	return results, nil
+}
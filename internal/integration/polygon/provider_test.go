@@ -0,0 +1,106 @@
+package polygon_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/polygon-io/client-go/rest/models"
+	"github.com/zheka156/market_data/internal/integration/polygon"
+	"github.com/zheka156/market_data/internal/provider"
+	"go.uber.org/zap"
+)
+
+// fakePolygon serves daily bars opening at midnight Eastern time and knows AAPL only
+type fakePolygon struct {
+	polygon.Polygon
+	aggs         polygon.AggregatesParams
+	detailsCalls int
+}
+
+func (f *fakePolygon) GetAggregates(params polygon.AggregatesParams) ([]models.Agg, error) {
+	f.aggs = params
+	var aggs []models.Agg
+	for day := params.From.Truncate(24 * time.Hour); day.Before(params.To); day = day.AddDate(0, 0, 1) {
+		aggs = append(aggs, models.Agg{Open: 1, High: 2, Low: 0.5, Close: 1.5, Volume: 100, Timestamp: models.Millis(day.Add(4 * time.Hour))})
+	}
+	return aggs, nil
+}
+
+func (f *fakePolygon) GetTickerDetails(ticker string) (*models.Ticker, error) {
+	f.detailsCalls++
+	if ticker != "AAPL" {
+		return nil, polygon.ErrUnknownTicker
+	}
+	return &models.Ticker{Ticker: "AAPL", Name: "Apple Inc.", PrimaryExchange: "XNAS", CurrencyName: "usd", Active: true}, nil
+}
+
+func TestHistoryDailyBarsOfSessionDates(t *testing.T) {
+	client := &fakePolygon{}
+	p := polygon.NewProvider(zap.NewNop(), client)
+	day := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+
+	candles, err := p.History(provider.Asset{Symbol: "AAPL", Quote: "USD", Class: provider.Stock}, day, day, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(candles) != 1 || !candles[0].OpenTime.Equal(day) {
+		t.Fatalf("expected the bar of %s, got %+v", day, candles)
+	}
+	if client.aggs.Timespan != models.Day || client.aggs.Multiplier != 1 {
+		t.Errorf("expected 1 day aggregates, got %+v", client.aggs)
+	}
+
+	if _, err := p.History(provider.Asset{Symbol: "AAPL"}, day, day, 90*time.Second); !errors.Is(err, provider.ErrUnsupported) {
+		t.Errorf("expected unsupported interval error, got %v", err)
+	}
+}
+
+func TestDetailsCachesUnknownTickers(t *testing.T) {
+	client := &fakePolygon{}
+	p := polygon.NewProvider(zap.NewNop(), client)
+
+	details, err := p.Details("AAPL")
+	if err != nil || details.Name != "Apple Inc." || details.PrimaryExchange != "XNAS" {
+		t.Fatalf("unexpected details %+v (%v)", details, err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := p.Details("NOPE"); !errors.Is(err, polygon.ErrUnknownTicker) {
+			t.Errorf("expected unknown ticker error, got %v", err)
+		}
+	}
+	if client.detailsCalls != 2 {
+		t.Errorf("expected one request per ticker, got %d", client.detailsCalls)
+	}
+}
+
+func (f *fakePolygon) GetGroupedDaily(date time.Time) ([]models.Agg, error) {
+	if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+		return nil, polygon.ErrNoData
+	}
+	return []models.Agg{
+		{Ticker: "AAPL", Open: 230, High: 235, Low: 229, Close: 234.5, Volume: 1000},
+		{Ticker: "MSFT", Open: 510, High: 515, Low: 505, Close: 512, Volume: 500},
+		{Ticker: "IBM", Open: 250, High: 252, Low: 248, Close: 251, Volume: 200},
+	}, nil
+}
+
+func TestGroupedDailyKeepsTrackedTickers(t *testing.T) {
+	p := polygon.NewProvider(zap.NewNop(), &fakePolygon{})
+	day := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+
+	candles, err := p.GroupedDaily(day, []string{"AAPL", "MSFT", "NVDA"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(candles) != 2 {
+		t.Fatalf("expected bars of AAPL and MSFT, got %+v", candles)
+	}
+	if aapl := candles["AAPL"]; aapl.Close.String() != "234.5" || !aapl.OpenTime.Equal(day) {
+		t.Errorf("unexpected AAPL bar %+v", aapl)
+	}
+
+	if _, err := p.GroupedDaily(day.AddDate(0, 0, 1), []string{"AAPL"}); !errors.Is(err, polygon.ErrNoData) {
+		t.Errorf("expected no data on a closed market, got %v", err)
+	}
+}
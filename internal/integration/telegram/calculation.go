@@ -16,7 +16,7 @@ import (
 )
 
 const coinInfoTemplate = `
-🔹%s🔹%s tokens worth %s USDT
+🔹%s🔹%s tokens worth %s %s%s
 `
 
 func (bc *BotClient) provideCalculationByQuantityCommandHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
@@ -43,6 +43,7 @@ func (bc *BotClient) provideCalculationByQuantityCommandHandler(ctx context.Cont
 		return
 	}
 
+	chatID := strconv.FormatInt(update.Message.Chat.ID, 10)
 	coinStorage := make(map[string]CoinInfo)
 	for i, val := range quantityList {
 		quantity, err := decimal.NewFromString(val)
@@ -54,11 +55,7 @@ func (bc *BotClient) provideCalculationByQuantityCommandHandler(ctx context.Cont
 			return
 		}
 
-		price, err := bc.Rep.GetLastHourPriceBySymbol(uniqueUserCoinsToSave[i])
-		if err != nil {
-			bc.Logger.Sugar().Error("Failed to get price from repository for coin", err)
-			price = nil
-		}
+		price := bc.getLastPrice(uniqueUserCoinsToSave[i])
 		amount := utils.CalculateAmount(quantity, price.Last_price)
 
 		coinStorage[uniqueUserCoinsToSave[i]] = CoinInfo{
@@ -66,16 +63,17 @@ func (bc *BotClient) provideCalculationByQuantityCommandHandler(ctx context.Cont
 			Price:     price.Last_price,
 			Amount:    amount,
 			UpdatedAt: price.TS,
+			Change24h: bc.getChange24h(uniqueUserCoinsToSave[i]),
 		}
 	}
 
+	valued, currency := bc.convertCoins(coinStorage, bc.chatCurrency(chatID))
 	b.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID: update.Message.Chat.ID,
-		Text:   prepareResponseWithCoinsInfo(coinStorage),
+		Text:   prepareResponseWithCoinsInfo(valued, currency),
 		ReplyMarkup: keyboard_builder.ManageCoinsKeyboard(),
 	})
 
-	chatID := strconv.FormatInt(update.Message.Chat.ID, 10)
 	err := bc.Rep.CreateChat(chatID)
 	if err != nil {
 		b.SendMessage(ctx, &bot.SendMessageParams{
@@ -94,7 +92,7 @@ func (bc *BotClient) provideCalculationByQuantityCommandHandler(ctx context.Cont
 	}
 }
 
-func prepareResponseWithCoinsInfo(coins map[string]CoinInfo) string {
+func prepareResponseWithCoinsInfo(coins map[string]CoinInfo, currency string) string {
 	var response string
 	var updatedAt time.Time
 	var sumOfAmounts decimal.Decimal
@@ -107,12 +105,24 @@ func prepareResponseWithCoinsInfo(coins map[string]CoinInfo) string {
 
 	for _, k := range keys {
 		v := coins[k]
-		response += fmt.Sprintf(coinInfoTemplate, k, v.Quantity.Round(2).String(), v.Amount.String())
+		response += fmt.Sprintf(coinInfoTemplate, k, v.Quantity.Round(2).String(), v.Amount.String(), currency, formatChange24h(v.Change24h))
 		sumOfAmounts = sumOfAmounts.Add(v.Amount)
 		updatedAt = v.UpdatedAt
 	}
 	response += fmt.Sprintf(`
-	📊Total Value: %s USDT
-	📅 Updated at: %s UTC`, sumOfAmounts.String(), updatedAt.Format("2006-01-02 15:04"))
+	📊Total Value: %s %s
+	📅 Updated at: %s UTC`, sumOfAmounts.String(), currency, updatedAt.Format("2006-01-02 15:04"))
 	return response
 }
+
+// formatChange24h renders the 24 hour change as " (24h: +1.23%)", empty if unknown
+func formatChange24h(change *decimal.Decimal) string {
+	if change == nil {
+		return ""
+	}
+	sign := ""
+	if change.IsPositive() {
+		sign = "+"
+	}
+	return fmt.Sprintf(" (24h: %s%s%%)", sign, change.StringFixed(2))
+}
@@ -6,7 +6,9 @@ import (
 	"regexp"
 
 	"github.com/go-telegram/bot"
+	"github.com/zheka156/market_data/internal/fx"
 	"github.com/zheka156/market_data/internal/postgres"
+	"github.com/zheka156/market_data/internal/provider"
 	"github.com/zheka156/market_data/internal/utils"
 	"go.uber.org/zap"
 )
@@ -15,11 +17,12 @@ type BotClient struct {
 	*bot.Bot
 	Logger *zap.Logger
 	Rep    postgres.Repository
+	Prices *provider.Registry
+	// FX values coins in the currency of the chat, nil keeps them in USDT
+	FX *fx.Service
 }
 
-var repositoryCoins = make(map[string]struct{})
-
-func NewBot(ctx context.Context, logger *zap.Logger, rep postgres.Repository) {
+func NewBot(ctx context.Context, logger *zap.Logger, rep postgres.Repository, prices *provider.Registry, rates *fx.Service) {
 
 	token := os.Getenv("TG_TKN")
 
@@ -33,16 +36,13 @@ func NewBot(ctx context.Context, logger *zap.Logger, rep postgres.Repository) {
 		b,
 		logger,
 		rep,
-	}
-
-	coinsList, err := rep.GetTickers()
-	if err != nil {
-		logger.Sugar().Errorf("failed to get tickers from repository", err)
+		prices,
+		rates,
 	}
 
 	//creating cache of coins
-	for _, c := range coinsList {
-		repositoryCoins[c] = struct{}{}
+	if err := bc.loadCoins(); err != nil {
+		logger.Sugar().Errorf("failed to get tickers from repository", err)
 	}
 	logger.Info("Loaded cache of coins")
 
@@ -56,6 +56,7 @@ func NewBot(ctx context.Context, logger *zap.Logger, rep postgres.Repository) {
 	b.RegisterHandler(bot.HandlerTypeMessageText, "Erase input", bot.MatchTypeExact, bc.eraseInputCommandHandler)
 	b.RegisterHandler(bot.HandlerTypeMessageText, "Home", bot.MatchTypeExact, bc.mainMenuButtonHandler)
 	b.RegisterHandler(bot.HandlerTypeMessageText, "Input Manually", bot.MatchTypeExact, bc.manualCoinInputMessageHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/currency", bot.MatchTypePrefix, bc.currencyCommandHandler)
 
 	numberRegexp := regexp.MustCompile(`^\d+(\.\d+)?(,\d+(\.\d+)?)*$`)
 	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, numberRegexp, bc.provideCalculationByQuantityCommandHandler)
@@ -65,11 +66,11 @@ func NewBot(ctx context.Context, logger *zap.Logger, rep postgres.Repository) {
 	removalRegexp, _ := utils.CreateRemoveRegexp()
 	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, removalRegexp, bc.removeCoinCommandHandler)
 
-	coinQuantityRegexp, _ := utils.CreateCoinQuantityRegexp(coinsList)
-	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, coinQuantityRegexp, bc.addNewCoinCommandHandler)
+	matchCoinQuantity := bc.matchCoins(func(c *coinCache) *regexp.Regexp { return c.quantity })
+	b.RegisterHandlerMatchFunc(matchCoinQuantity, bc.addNewCoinCommandHandler)
 
-	coinRegexp, _ := utils.CreateCoinRegexp(coinsList)
-	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, coinRegexp, bc.manualCoinInputMessageHandler)
+	matchCoin := bc.matchCoins(func(c *coinCache) *regexp.Regexp { return c.coin })
+	b.RegisterHandlerMatchFunc(matchCoin, bc.manualCoinInputMessageHandler)
 
 	bc.Logger.Info("Starting telegram bot")
 	bc.Start(ctx)
@@ -111,7 +111,7 @@ func (bc *BotClient) manualCoinInputMessageHandler(ctx context.Context, b *bot.B
 	userCoins := utils.GetTickersFromUserInput(update.Message.Text)
 	mergedCoins := append(userCoins, chosenCoinOptions...)
 	uniqueCoins := utils.RemoveDuplicates(mergedCoins)
-	found, notFound := findCoinsInRepo(uniqueCoins)
+	found, notFound := bc.findCoinsInRepo(uniqueCoins)
 
 	chatID := strconv.FormatInt(update.Message.Chat.ID, 10)
 
@@ -138,18 +138,6 @@ func (bc *BotClient) manualCoinInputMessageHandler(ctx context.Context, b *bot.B
 	})
 }
 
-func findCoinsInRepo(input []string) (found, notFound []string) {
-	for _, val := range input {
-		if _, ok := repositoryCoins[val]; ok {
-			found = append(found, val)
-			continue
-		} else {
-			notFound = append(notFound, val)
-		}
-	}
-	return found, notFound
-}
-
 func (bc *BotClient) eraseInputCommandHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
 	chosenCoinOptions = []string{}
 	b.SendMessage(ctx, &bot.SendMessageParams{
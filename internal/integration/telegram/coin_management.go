@@ -11,6 +11,8 @@ import (
 	"github.com/go-telegram/bot/models"
 	"github.com/shopspring/decimal"
 	"github.com/zheka156/market_data/internal/integration/telegram/keyboard_builder"
+	"github.com/zheka156/market_data/internal/postgres"
+	"github.com/zheka156/market_data/internal/provider"
 	"github.com/zheka156/market_data/internal/utils"
 )
 
@@ -19,6 +21,44 @@ type CoinInfo struct {
 	Price     decimal.Decimal
 	Amount    decimal.Decimal
 	UpdatedAt time.Time
+	// Change24h is the price change in percent over the last 24 hours, nil if unknown
+	Change24h *decimal.Decimal
+}
+
+// getLastPrice returns the last stored USDT price of the coin,
+// the price provider is asked if the coin has no stored price yet
+func (bc *BotClient) getLastPrice(coin string) *postgres.Price {
+	price, err := bc.Rep.GetLastHourPriceBySymbol(coin, utils.USDT)
+	if err == nil {
+		return price
+	}
+	bc.Logger.Sugar().Error("Failed to get price from repository for coin", err)
+
+	quote, err := bc.Prices.LastPrice(provider.Asset{Symbol: coin, Quote: utils.USDT, Class: provider.Crypto})
+	if err != nil {
+		bc.Logger.Sugar().Error("Failed to get price from provider for coin", err)
+		return &postgres.Price{Fromsymbol: coin, Tosymbol: utils.USDT}
+	}
+	return &postgres.Price{
+		Fromsymbol: coin,
+		Tosymbol:   utils.USDT,
+		Last_price: quote.Price,
+		TS:         quote.Time,
+		SourceTS:   quote.Time,
+	}
+}
+
+// getChange24h returns the stored 24 hour price change of the coin in percent, nil if unknown
+func (bc *BotClient) getChange24h(coin string) *decimal.Decimal {
+	stats, err := bc.Rep.GetLastTickerStats(coin)
+	if err != nil {
+		bc.Logger.Sugar().Error("Failed to get 24h stats from repository for coin", err)
+		return nil
+	}
+	if stats == nil {
+		return nil
+	}
+	return &stats.PriceChangePercent
 }
 
 func (bc *BotClient) showMyCoinsCommandHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
@@ -42,22 +82,20 @@ func (bc *BotClient) showMyCoinsCommandHandler(ctx context.Context, b *bot.Bot,
 
 	coinData := make(map[string]CoinInfo)
 	for _, coin := range coins {
-		price, err := bc.Rep.GetLastHourPriceBySymbol(coin.Coin)
-		if err != nil {
-			bc.Logger.Sugar().Error("Failed to get price from repository for coin", err)
-			price = nil
-		}
+		price := bc.getLastPrice(coin.Coin)
 		amount := utils.CalculateAmount(coin.Quantity, price.Last_price)
 		coinData[coin.Coin] = CoinInfo{
 			Quantity:  coin.Quantity,
 			Price:     price.Last_price,
 			Amount:    amount,
 			UpdatedAt: price.TS,
+			Change24h: bc.getChange24h(coin.Coin),
 		}
 	}
+	valued, currency := bc.convertCoins(coinData, bc.chatCurrency(chatID))
 	b.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID:      update.Message.Chat.ID,
-		Text:        prepareResponseWithCoinsInfo(coinData),
+		Text:        prepareResponseWithCoinsInfo(valued, currency),
 		ReplyMarkup: keyboard_builder.ManageCoinsKeyboard(),
 	})
 }
@@ -211,7 +249,7 @@ func (bc *BotClient) removeCoinCommandHandler(ctx context.Context, b *bot.Bot, u
 
 		coinsList := utils.GetTickersFromUserInput(coinsToDelete)
 
-		found, _ := findCoinsInRepo(coinsList)
+		found, _ := bc.findCoinsInRepo(coinsList)
 		if len(found) == 0 {
 			bc.Logger.Info("No coins found for removal")
 			b.SendMessage(ctx, &bot.SendMessageParams{
@@ -0,0 +1,121 @@
+package telegram
+
+import (
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+	"github.com/zheka156/market_data/internal/utils"
+)
+
+// coinsRefreshInterval limits reloading coins on unknown input, a coin added
+// while the bot runs is known to it at most this long after it was first asked for
+const coinsRefreshInterval = time.Minute
+
+// coinCache keeps the tickers of the repository and the regexps finding them in messages
+type coinCache struct {
+	mu          sync.RWMutex
+	tickers     map[string]struct{}
+	coin        *regexp.Regexp
+	quantity    *regexp.Regexp
+	refreshedAt time.Time
+}
+
+var repositoryCoins = &coinCache{tickers: make(map[string]struct{})}
+
+func (c *coinCache) contains(ticker string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.tickers[ticker]
+	return ok
+}
+
+// loadCoins replaces the cached coins with the tickers of the repository
+func (bc *BotClient) loadCoins() error {
+	coinsList, err := bc.Rep.GetTickers()
+	if err != nil {
+		return err
+	}
+	tickers := make(map[string]struct{}, len(coinsList))
+	for _, c := range coinsList {
+		tickers[c] = struct{}{}
+	}
+	// an empty alternation matches every word, no coins match no messages
+	var coinRegexp, coinQuantityRegexp *regexp.Regexp
+	if len(coinsList) != 0 {
+		if coinRegexp, err = utils.CreateCoinRegexp(coinsList); err != nil {
+			return err
+		}
+		if coinQuantityRegexp, err = utils.CreateCoinQuantityRegexp(coinsList); err != nil {
+			return err
+		}
+	}
+
+	repositoryCoins.mu.Lock()
+	defer repositoryCoins.mu.Unlock()
+	repositoryCoins.tickers = tickers
+	repositoryCoins.coin = coinRegexp
+	repositoryCoins.quantity = coinQuantityRegexp
+	repositoryCoins.refreshedAt = time.Now()
+	return nil
+}
+
+// refreshCoins reloads the coins unless they were refreshed within coinsRefreshInterval
+func (bc *BotClient) refreshCoins() {
+	repositoryCoins.mu.Lock()
+	if time.Since(repositoryCoins.refreshedAt) < coinsRefreshInterval {
+		repositoryCoins.mu.Unlock()
+		return
+	}
+	// a failed reload waits for the interval too
+	repositoryCoins.refreshedAt = time.Now()
+	repositoryCoins.mu.Unlock()
+
+	if err := bc.loadCoins(); err != nil {
+		bc.Logger.Sugar().Error("Failed to refresh coins from repository", err)
+	}
+}
+
+// findCoinsInRepo splits the input into known and unknown coins,
+// unknown ones refresh the cache in case they were added after it was loaded
+func (bc *BotClient) findCoinsInRepo(input []string) (found, notFound []string) {
+	found, notFound = repositoryCoins.find(input)
+	if len(notFound) == 0 {
+		return found, notFound
+	}
+	bc.refreshCoins()
+	return repositoryCoins.find(input)
+}
+
+func (c *coinCache) find(input []string) (found, notFound []string) {
+	for _, val := range input {
+		if c.contains(val) {
+			found = append(found, val)
+		} else {
+			notFound = append(notFound, val)
+		}
+	}
+	return found, notFound
+}
+
+// matchCoins matches message texts by the cached regexp pick returns,
+// a text it doesn't match refreshes the cache and is matched again
+func (bc *BotClient) matchCoins(pick func(c *coinCache) *regexp.Regexp) func(update *models.Update) bool {
+	match := func(text string) bool {
+		repositoryCoins.mu.RLock()
+		defer repositoryCoins.mu.RUnlock()
+		re := pick(repositoryCoins)
+		return re != nil && re.MatchString(text)
+	}
+	return func(update *models.Update) bool {
+		if update.Message == nil {
+			return false
+		}
+		if match(update.Message.Text) {
+			return true
+		}
+		bc.refreshCoins()
+		return match(update.Message.Text)
+	}
+}
@@ -0,0 +1,100 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/zheka156/market_data/internal/integration/telegram/keyboard_builder"
+	"github.com/zheka156/market_data/internal/utils"
+)
+
+const currencyMessage = `
+	💱 Coins are valued in *%s*
+To change it, type: /currency EUR
+Available: %s`
+
+// currencyCommandHandler shows or sets the currency coins of the chat are valued in
+func (bc *BotClient) currencyCommandHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := strconv.FormatInt(update.Message.Chat.ID, 10)
+	available := []string{utils.USDT}
+	if bc.FX != nil {
+		available = append(available, bc.FX.Currencies()...)
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(strings.TrimPrefix(update.Message.Text, "/currency")))
+	if currency == "" {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    update.Message.Chat.ID,
+			Text:      fmt.Sprintf(currencyMessage, bc.chatCurrency(chatID), strings.Join(available, ", ")),
+			ParseMode: models.ParseModeMarkdownV1,
+		})
+		return
+	}
+	if currency != utils.USDT && (bc.FX == nil || !bc.FX.Supports(currency)) {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("Currency %s is not supported. Available: %s", currency, strings.Join(available, ", ")),
+		})
+		return
+	}
+
+	// USDT is the stored value, it needs no conversion
+	stored := currency
+	if currency == utils.USDT {
+		stored = ""
+	}
+	if err := bc.Rep.SetChatCurrency(chatID, stored); err != nil {
+		bc.Logger.Sugar().Error("Failed to save chat currency", err)
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Failed to save currency, please try again later",
+		})
+		return
+	}
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      update.Message.Chat.ID,
+		Text:        fmt.Sprintf("✅ Coins are valued in *%s* now", currency),
+		ParseMode:   models.ParseModeMarkdownV1,
+		ReplyMarkup: keyboard_builder.ManageCoinsKeyboard(),
+	})
+}
+
+// chatCurrency returns the currency chosen by the chat, USDT by default
+func (bc *BotClient) chatCurrency(chatID string) string {
+	currency, err := bc.Rep.GetChatCurrency(chatID)
+	if err != nil {
+		bc.Logger.Sugar().Error("Failed to get chat currency", err)
+	}
+	if currency == "" || bc.FX == nil {
+		return utils.USDT
+	}
+	return currency
+}
+
+// convertCoins values coin amounts in the currency at the rate of their price time.
+// If a rate is missing all amounts stay in USDT so the total isn't mixed.
+func (bc *BotClient) convertCoins(coins map[string]CoinInfo, currency string) (map[string]CoinInfo, string) {
+	if currency == utils.USDT {
+		return coins, utils.USDT
+	}
+	converted := make(map[string]CoinInfo, len(coins))
+	for coin, info := range coins {
+		if info.Amount.IsZero() {
+			converted[coin] = info
+			continue
+		}
+		rate, err := bc.FX.Rate(currency, info.UpdatedAt)
+		if err != nil {
+			bc.Logger.Sugar().Errorf("Failed to get %s rate, coins are valued in USDT: %s", currency, err)
+			return coins, utils.USDT
+		}
+		info.Price = info.Price.Mul(rate)
+		info.Amount = info.Amount.Mul(rate).Round(2)
+		converted[coin] = info
+	}
+	return converted, currency
+}
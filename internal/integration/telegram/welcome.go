@@ -13,6 +13,7 @@ Keep a track of your crypto investments! Just select your cryptocurrency, enter
 
 🔹 Stay updated with latest prices
 🔹 Track your portfolio effortlessly
+🔹 Value it in your currency with /currency
 
 Ready to get started? Choose your first cryptocurrency now! 🚀📈`
 
@@ -0,0 +1,186 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/zheka156/market_data/internal/integration/binance"
+	"github.com/zheka156/market_data/internal/postgres"
+	"go.uber.org/zap"
+)
+
+const (
+	CatalogJobName = "coin_catalog"
+
+	catalogInterval = 24 * time.Hour
+	catalogOffset   = 30 * time.Minute
+	// coin.ticker column width
+	maxTickerLength = 10
+)
+
+func CatalogJob(ctx context.Context, params JobParams) {
+	logger := params.Log
+
+	for waitNextRun(ctx, catalogInterval, catalogOffset) {
+		logger.Info("Coin catalog job started")
+		err := params.trackRun(ctx, CatalogJobName, func() (int, error) {
+			report, err := params.SyncCatalog(RunOptions{})
+			return report.Processed, err
+		})
+		if err != nil {
+			logger.Error("Failed to sync coin catalog", zap.Error(err))
+			continue
+		}
+		logger.Info("Coin catalog job stopped")
+	}
+}
+
+// SyncCatalog discovers coins traded against the configured quote assets,
+// stores their precision and flags coins that are no longer traded
+func (p JobParams) SyncCatalog(opts RunOptions) (*RunReport, error) {
+	report := &RunReport{Job: CatalogJobName, DryRun: opts.DryRun}
+	if len(opts.Symbols) != 0 {
+		return report, ErrSymbolsUnsupported
+	}
+
+	var info *binance.ExchangeInfo
+	err := p.Prices.Track(binance.ProviderName, 1, func() (err error) {
+		info, err = p.Client.GetExchangeInfo()
+		return err
+	})
+	if err != nil {
+		return report, err
+	}
+	coins := p.catalogFromExchange(info.Symbols)
+	if len(coins) == 0 {
+		// an empty response would flag every coin as delisted
+		return report, errors.New("no traded symbols found in exchange info")
+	}
+	coins = append(coins, p.pinnedCoins(coins)...)
+
+	current, err := p.Rep.GetCatalogCoins()
+	if err != nil {
+		return report, err
+	}
+	known := make(map[string]*postgres.CatalogCoin, len(current))
+	for _, coin := range current {
+		known[coin.Ticker] = coin
+	}
+	traded := make(map[string]struct{}, len(coins))
+	for _, coin := range coins {
+		traded[coin.Ticker] = struct{}{}
+		if _, ok := known[coin.Ticker]; !ok {
+			report.Added = append(report.Added, coin.Ticker)
+		}
+	}
+	for _, coin := range current {
+		// coins pinned to another price source are not listed on the exchange
+		if _, ok := traded[coin.Ticker]; !ok && coin.IsTradeable && coin.PriceSource == "" {
+			report.Delisted = append(report.Delisted, coin.Ticker)
+		}
+	}
+
+	if opts.DryRun {
+		return report, nil
+	}
+	if err := p.Rep.SyncCoinCatalog(coins); err != nil {
+		return report, err
+	}
+	report.Processed = len(coins)
+
+	if len(report.Delisted) != 0 && p.Alerts != nil {
+		p.Alerts.Notify(context.Background(), fmt.Sprintf("Coins %v are no longer traded on binance", report.Delisted))
+	}
+	p.Log.Info("Coin catalog synced",
+		zap.Int("coins", len(coins)), zap.Strings("added", report.Added), zap.Strings("delisted", report.Delisted))
+	return report, nil
+}
+
+// catalogFromExchange picks one pair per base asset using the quote override of the coin
+// or the quote assets preference
+func (p JobParams) catalogFromExchange(symbols []binance.SymbolInfo) []*postgres.CatalogCoin {
+	quotes := p.Catalog.QuoteAssets
+	if len(quotes) == 0 {
+		quotes = []string{USDT}
+	}
+	rank := make(map[string]int, len(quotes))
+	for i, quote := range quotes {
+		rank[quote] = i
+	}
+	allow := toSet(p.Catalog.Allowlist)
+	deny := toSet(p.Catalog.Denylist)
+
+	best := make(map[string]binance.SymbolInfo)
+	for _, symbol := range symbols {
+		if symbol.Status != binance.SymbolStatusTrading {
+			continue
+		}
+		// a pinned coin has a single candidate pair whatever the preference is
+		override, pinned := p.Catalog.QuoteOverrides[symbol.BaseAsset]
+		r, ranked := rank[symbol.QuoteAsset]
+		if pinned && symbol.QuoteAsset != override || !pinned && !ranked {
+			continue
+		}
+		if len(symbol.BaseAsset) > maxTickerLength {
+			p.Log.Debug("Skipping coin with long ticker", zap.String("coin", symbol.BaseAsset))
+			continue
+		}
+		if current, ok := best[symbol.BaseAsset]; ok && rank[current.QuoteAsset] <= r {
+			continue
+		}
+		best[symbol.BaseAsset] = symbol
+	}
+
+	coins := make([]*postgres.CatalogCoin, 0, len(best))
+	for base, symbol := range best {
+		_, allowed := allow[base]
+		_, denied := deny[base]
+		coins = append(coins, &postgres.CatalogCoin{
+			Ticker:        base,
+			Name:          base,
+			Precision:     symbol.PricePrecision(),
+			StepPrecision: symbol.QuantityPrecision(),
+			QuoteAsset:    symbol.QuoteAsset,
+			IsTradeable:   true,
+			IsVisible:     allowed && !denied,
+			IsDenied:      denied,
+		})
+	}
+	return coins
+}
+
+// pinnedCoins are coins the providers configuration routes to another provider and the exchange
+// doesn't trade, e.g. fiat only kraken lists. They are priced in USDT from their provider.
+func (p JobParams) pinnedCoins(traded []*postgres.CatalogCoin) []*postgres.CatalogCoin {
+	listed := make(map[string]struct{}, len(traded))
+	for _, coin := range traded {
+		listed[coin.Ticker] = struct{}{}
+	}
+	var coins []*postgres.CatalogCoin
+	for symbol, source := range p.Prices.PinnedSymbols() {
+		if _, ok := listed[symbol]; ok || source == binance.ProviderName || len(symbol) > maxTickerLength {
+			continue
+		}
+		coins = append(coins, &postgres.CatalogCoin{
+			Ticker:        symbol,
+			Name:          symbol,
+			Precision:     8,
+			StepPrecision: 8,
+			QuoteAsset:    USDT,
+			IsTradeable:   true,
+			IsVisible:     true,
+			PriceSource:   source,
+		})
+	}
+	return coins
+}
+
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		set[value] = struct{}{}
+	}
+	return set
+}
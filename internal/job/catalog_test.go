@@ -0,0 +1,128 @@
+package job
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zheka156/market_data/internal/config"
+	"github.com/zheka156/market_data/internal/integration/binance"
+	"github.com/zheka156/market_data/internal/integration/binance/binancetest"
+	"github.com/zheka156/market_data/internal/postgres"
+	"github.com/zheka156/market_data/internal/provider"
+	"go.uber.org/zap"
+)
+
+// catalogRepository keeps the synced catalog like the coin table does
+type catalogRepository struct {
+	fakeRepository
+	coins map[string]*postgres.CatalogCoin
+}
+
+func (r *catalogRepository) GetCatalogCoins() ([]*postgres.CatalogCoin, error) {
+	coins := make([]*postgres.CatalogCoin, 0, len(r.coins))
+	for _, coin := range r.coins {
+		coins = append(coins, coin)
+	}
+	return coins, nil
+}
+
+func (r *catalogRepository) SyncCoinCatalog(coins []*postgres.CatalogCoin) error {
+	synced := make(map[string]bool, len(coins))
+	for _, coin := range coins {
+		if stored, ok := r.coins[coin.Ticker]; ok && stored.IsVisible && !coin.IsDenied {
+			coin.IsVisible = true
+		}
+		r.coins[coin.Ticker] = coin
+		synced[coin.Ticker] = true
+	}
+	for ticker, coin := range r.coins {
+		if !synced[ticker] && coin.PriceSource == "" {
+			coin.IsTradeable = false
+		}
+	}
+	return nil
+}
+
+// namedProvider only has a name, the catalog doesn't ask providers for prices
+type namedProvider struct {
+	provider.PriceProvider
+	name string
+}
+
+func (p namedProvider) Name() string {
+	return p.name
+}
+
+func newTestCatalogClient(t *testing.T, logger *zap.Logger) (*binance.Client, *binancetest.Server) {
+	t.Helper()
+	fake, server := binancetest.NewServer()
+	t.Cleanup(server.Close)
+	t.Setenv("BINANCE_URL", server.URL)
+	return binance.NewClient(logger, &config.Config{Binance: config.Binance{MaxRetries: 1, MaxRetryWait: time.Second}}), fake
+}
+
+func TestSyncCatalogKeepsPinnedCoins(t *testing.T) {
+	logger := zap.NewNop()
+	client, fake := newTestCatalogClient(t, logger)
+	fake.SetPrice("BTC", USDT, "65000")
+
+	prices, err := provider.NewRegistry(logger, config.Providers{
+		Classes: map[string][]string{"crypto": {binance.ProviderName, "kraken"}},
+		Symbols: map[string][]string{"GBP": {"kraken"}},
+	}, binance.NewProvider(logger, client), namedProvider{name: "kraken"})
+	if err != nil {
+		t.Fatalf("failed to create registry: %s", err)
+	}
+	rep := &catalogRepository{coins: map[string]*postgres.CatalogCoin{}}
+	params := *NewJobParams(logger, client, prices, rep, nil, nil, config.Jobs{})
+
+	for run := 0; run < 2; run++ {
+		report, err := params.SyncCatalog(RunOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(report.Delisted) != 0 {
+			t.Errorf("expected no delisted coins on run %d, got %v", run, report.Delisted)
+		}
+	}
+	gbp := rep.coins["GBP"]
+	if gbp == nil || !gbp.IsTradeable || gbp.PriceSource != "kraken" || gbp.QuoteAsset != USDT {
+		t.Fatalf("expected GBP priced from kraken, got %+v", gbp)
+	}
+	if btc := rep.coins["BTC"]; btc == nil || btc.PriceSource != "" {
+		t.Errorf("expected BTC from the exchange, got %+v", btc)
+	}
+	if len(rep.coins) != 2 {
+		t.Errorf("unexpected catalog %v", rep.coins)
+	}
+}
+
+func TestSyncCatalogHidesNewCoins(t *testing.T) {
+	logger := zap.NewNop()
+	client, fake := newTestCatalogClient(t, logger)
+	for _, coin := range []string{"BTC", "ETH", "SOL", "DOGE"} {
+		fake.SetPrice(coin, USDT, "1")
+	}
+	prices, err := provider.NewRegistry(logger, config.Providers{
+		Classes: map[string][]string{"crypto": {binance.ProviderName}},
+	}, binance.NewProvider(logger, client))
+	if err != nil {
+		t.Fatalf("failed to create registry: %s", err)
+	}
+	rep := &catalogRepository{coins: map[string]*postgres.CatalogCoin{
+		"ETH":  {Ticker: "ETH", QuoteAsset: USDT, IsTradeable: true, IsVisible: true},
+		"DOGE": {Ticker: "DOGE", QuoteAsset: USDT, IsTradeable: true, IsVisible: true},
+	}}
+	params := *NewJobParams(logger, client, prices, rep, nil, nil, config.Jobs{
+		Catalog: config.CatalogJob{Allowlist: []string{"SOL"}, Denylist: []string{"DOGE"}},
+	})
+
+	if _, err := params.SyncCatalog(RunOptions{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for ticker, visible := range map[string]bool{"BTC": false, "ETH": true, "SOL": true, "DOGE": false} {
+		if coin := rep.coins[ticker]; coin == nil || coin.IsVisible != visible {
+			t.Errorf("expected %s visible %t, got %+v", ticker, visible, coin)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/zheka156/market_data/internal/postgres"
+	"github.com/zheka156/market_data/internal/provider"
+	"go.uber.org/zap"
+)
+
+// catchUp runs the job right away if the newest stored price is older than the job interval,
+// buckets missed in between are restored from klines up to MaxBackfill back
+func (p JobParams) catchUp(ctx context.Context) {
+	latest, err := p.Rep.GetLatestPriceTime()
+	if err != nil {
+		p.Log.Error("Failed to check prices freshness", zap.Error(err))
+		return
+	}
+
+	current := time.Now().UTC().Truncate(p.Delay)
+	if latest != nil && !latest.Before(current) {
+		p.Log.Info("Prices are up to date, catch-up is not needed", zap.Time("latest", *latest))
+		return
+	}
+
+	from := current.Add(-p.MaxBackfill)
+	if latest != nil && latest.After(from) {
+		from = latest.Add(p.Delay)
+	}
+	if from.Before(current) {
+		p.Log.Info("Backfilling missed prices", zap.Time("from", from), zap.Time("to", current))
+		err = p.trackRun(ctx, HourBackfillJobName, func() (int, error) {
+			return p.Backfill(from, current)
+		})
+		if err != nil {
+			p.Log.Error("Failed to backfill missed prices", zap.Error(err))
+		}
+	}
+
+	p.Log.Info("Prices are stale, running catch-up")
+	err = p.trackRun(ctx, HourJobName, p.Process)
+	if err != nil {
+		p.Log.Error("Failed to run catch-up", zap.Error(err))
+	}
+}
+
+// Backfill stores open prices of the buckets in [from, to) and returns the number of restored coins.
+// Coins quoted in another asset are also valued in USDT with the open of the quote/USDT candle.
+// A coin that failed is skipped so the others are still restored.
+func (p JobParams) Backfill(from time.Time, to time.Time) (int, error) {
+	pairs, err := p.Rep.GetCoinPairs()
+	if err != nil {
+		return 0, err
+	}
+
+	getOpens := func(asset provider.Asset) (map[time.Time]provider.Candle, error) {
+		candles, err := p.Prices.History(asset, from, to.Add(-time.Millisecond), p.Delay)
+		if err != nil {
+			return nil, err
+		}
+		opens := make(map[time.Time]provider.Candle, len(candles))
+		for _, candle := range candles {
+			opens[candle.OpenTime] = candle
+		}
+		return opens, nil
+	}
+	rates := make(map[string]map[time.Time]provider.Candle)
+
+	processed := 0
+	var failed []string
+	for _, pair := range pairs {
+		coin := pair.Ticker
+		opens, err := getOpens(coinAsset(pair))
+		if err != nil {
+			p.Log.Error("Failed to get price history", zap.String("coin", coin), zap.Error(err))
+			failed = append(failed, coin)
+			continue
+		}
+
+		quoteRates, ok := rates[pair.QuoteAsset]
+		if !ok && pair.QuoteAsset != USDT {
+			quoteRates, err = getOpens(conversionAsset(pair.QuoteAsset))
+			if err != nil {
+				p.Log.Error("Failed to get conversion history", zap.String("quote", pair.QuoteAsset), zap.Error(err))
+				failed = append(failed, coin)
+				continue
+			}
+			rates[pair.QuoteAsset] = quoteRates
+		}
+		for openTime, candle := range opens {
+			row := &postgres.Price{
+				Fromsymbol: coin,
+				Tosymbol:   pair.QuoteAsset,
+				Last_price: candle.Open.Truncate(8),
+				TS:         openTime,
+				SourceTS:   openTime,
+				Sources:    []string{candle.Source},
+			}
+			rows := []*postgres.Price{row}
+			if rate, ok := quoteRates[openTime]; ok && rate.Open.IsPositive() {
+				rows = append(rows, crossRate(row, rate.Open))
+			}
+			for _, row := range rows {
+				err = p.Rep.InsertPrice(row)
+				if err != nil {
+					return processed, err
+				}
+			}
+		}
+		if len(opens) != 0 {
+			processed++
+		}
+	}
+	if len(failed) != 0 {
+		return processed, fmt.Errorf("failed to backfill coins %v", failed)
+	}
+	return processed, nil
+}
@@ -0,0 +1,33 @@
+package job
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/zheka156/market_data/internal/integration/binance"
+)
+
+func TestBackfillCountsCoins(t *testing.T) {
+	params, rep, fake := newTestJob(t, pair("BTC", USDT), pair("ETH", "BTC"))
+	fake.SetPrice("BTC", USDT, "65000")
+	fake.SetPrice("ETH", "BTC", "0.05")
+	to := time.Now().UTC().Truncate(time.Hour)
+
+	processed, err := params.Backfill(to.Add(-3*time.Hour), to)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if processed != 2 {
+		t.Errorf("expected 2 restored coins, got %d", processed)
+	}
+	// 3 buckets of BTC/USDT, ETH/BTC and ETH/USDT
+	if len(rep.prices) != 9 {
+		t.Fatalf("expected 9 stored prices, got %d", len(rep.prices))
+	}
+	for _, price := range rep.prices {
+		if !slices.Equal(price.Sources, []string{binance.ProviderName}) {
+			t.Errorf("expected %s/%s from binance, got %v", price.Fromsymbol, price.Tosymbol, price.Sources)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	FXRatesJobName = "fx_rates"
+
+	fxRatesInterval      = 24 * time.Hour
+	defaultFXRatesOffset = 16*time.Hour + 30*time.Minute
+)
+
+// FXRatesJob stores the latest fiat rates once a day
+func FXRatesJob(ctx context.Context, params JobParams) {
+	logger := params.Log
+	if params.FX == nil {
+		logger.Info("FX rates job is disabled, no rates service")
+		return
+	}
+
+	for waitNextRun(ctx, fxRatesInterval, params.FXRates.Offset) {
+		logger.Info("FX rates job started")
+		err := params.trackRun(ctx, FXRatesJobName, params.FX.Update)
+		if err != nil {
+			logger.Error("Failed to update fx rates", zap.Error(err))
+			continue
+		}
+		logger.Info("FX rates job stopped")
+	}
+}
@@ -2,122 +2,263 @@ package job
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/zheka156/market_data/internal/alert"
+	"github.com/zheka156/market_data/internal/config"
+	"github.com/zheka156/market_data/internal/fx"
 	"github.com/zheka156/market_data/internal/integration/binance"
 	"github.com/zheka156/market_data/internal/postgres"
+	"github.com/zheka156/market_data/internal/provider"
 	"github.com/zheka156/market_data/internal/utils"
 	"go.uber.org/zap"
 )
 
 const (
-	delay              = 1 * time.Hour
-	USDT               = "USDT"
+	defaultDelay       = 1 * time.Hour
+	defaultMaxBackfill = 48 * time.Hour
+	defaultRetention   = 90 * 24 * time.Hour
+	USDT               = utils.USDT
 	maxTickersPerBatch = 20
+
+	HourJobName         = "hour_price"
+	HourBackfillJobName = "hour_price_backfill"
 )
 
 type JobParams struct {
-	Log    *zap.Logger
-	Client binance.Binance
-	Rep    postgres.Repository
+	Log *zap.Logger
+	// Client serves binance specific data: 24hr stats and the coin catalog
+	Client      binance.Binance
+	Prices      *provider.Registry
+	Rep         postgres.Repository
+	Alerts      alert.Notifier
+	Delay       time.Duration
+	MaxBackfill time.Duration
+	Retention   time.Duration
+	Stock       config.StockJob
+	Catalog     config.CatalogJob
+	// FX stores fiat rates, nil disables the fx rates job
+	FX      *fx.Service
+	FXRates config.FXRatesJob
+	// Locks makes runs of a job exclusive across replicas, nil runs jobs without locking
+	Locks func(key int64) Lock
 }
 
-func NewJobParams(logger *zap.Logger, client binance.Binance, repository postgres.Repository) *JobParams {
-	return &JobParams{
-		Log:    logger,
-		Client: client,
-		Rep:    repository,
+func NewJobParams(logger *zap.Logger, client binance.Binance, prices *provider.Registry, repository postgres.Repository,
+	alerts alert.Notifier, rates *fx.Service, conf config.Jobs) *JobParams {
+	p := &JobParams{
+		Log:         logger,
+		Client:      client,
+		Prices:      prices,
+		Rep:         repository,
+		Alerts:      alerts,
+		Delay:       conf.HourPrice.Interval,
+		MaxBackfill: conf.HourPrice.MaxBackfill,
+		Retention:   time.Duration(conf.Retention.HourlyRetentionDays) * 24 * time.Hour,
+		Stock:       conf.Stock,
+		Catalog:     conf.Catalog,
+		FX:          rates,
+		FXRates:     conf.FXRates,
+		Locks: func(key int64) Lock {
+			return repository.NewAdvisoryLock(key)
+		},
+	}
+	if p.Delay <= 0 {
+		p.Delay = defaultDelay
+	}
+	if p.MaxBackfill <= 0 {
+		p.MaxBackfill = defaultMaxBackfill
+	}
+	if p.Retention <= 0 {
+		p.Retention = defaultRetention
 	}
+	if p.FXRates.Offset <= 0 {
+		p.FXRates.Offset = defaultFXRatesOffset
+	}
+	if p.Stock.LookbackDays <= 0 {
+		p.Stock.LookbackDays = defaultStockLookbackDays
+	}
+	return p
 }
 
-func HourJob(params JobParams) {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
+func HourJob(ctx context.Context, params JobParams) {
 	logger := params.Log
 
-	for {
-		now := time.Now()
-		nextHour := now.Truncate(time.Hour).Add(delay)
-		timeUntilNextHour := time.Until(nextHour)
+	params.catchUp(ctx)
 
-		timer := time.NewTicker(timeUntilNextHour)
-		defer timer.Stop()
-
-		select {
-		case <-ctx.Done():
-			return
-		case <-timer.C:
-			logger.Info("Hourly job started")
-			err := params.Process()
-			if err != nil {
-				logger.Error("Failed to update hourly price", zap.Error(err))
-				continue
-			}
-			logger.Info("Hourly job stopped")
+	for waitNextRun(ctx, params.Delay, 0) {
+		logger.Info("Hourly job started")
+		err := params.trackRun(ctx, HourJobName, params.Process)
+		if err != nil {
+			logger.Error("Failed to update hourly price", zap.Error(err))
+			params.alertOnBinanceLimits(err)
+			continue
 		}
+		logger.Info("Hourly job stopped")
 	}
 }
 
-func (p JobParams) Process() error {
+// Process stores the last price of every coin and returns the number of stored symbols
+func (p JobParams) Process() (int, error) {
+	report, err := p.ProcessPrices(RunOptions{})
+	return report.Processed, err
+}
 
-	coins, err := p.Rep.GetTickers()
-	if err != nil {
-		return err
+// fetchPrices agrees on prices between providers if consensus is enabled,
+// otherwise the first provider of an asset that answers is used
+func (p JobParams) fetchPrices(assets []provider.Asset) ([]provider.Quote, []provider.Asset, error) {
+	if p.Prices.ConsensusEnabled() {
+		return p.Prices.BatchConsensusPrice(assets)
+	}
+	return p.Prices.BatchLastPrice(assets)
+}
+
+// quoteSources returns the providers that contributed to the quote
+func quoteSources(quote provider.Quote) []string {
+	if len(quote.Sources) != 0 {
+		return quote.Sources
 	}
+	return []string{quote.Source}
+}
 
-	//retrieve prices for coins in batches, store the whole batch in memory
-	var queryParam string
-	var batchOfPrices []binance.Pair
-	for i := 0; i < len(coins); i += maxTickersPerBatch {
+// ProcessPrices retrieves the last price of the selected coins (all coins if none selected)
+// against their quote asset and stores them unless it is a dry run.
+// Coins quoted in another asset are also valued in USDT through the quote/USDT rate.
+// 24 hour stats of the stored pairs are saved in the same bucket.
+// A provider failure doesn't drop the bucket, the error is returned after the quotes that were
+// retrieved are stored.
+func (p JobParams) ProcessPrices(opts RunOptions) (*RunReport, error) {
+	report := &RunReport{Job: HourJobName, DryRun: opts.DryRun}
 
-		end := i + maxTickersPerBatch
-		if end > len(coins) {
-			end = len(coins)
-		}
-		chunk := coins[i:end]
+	pairs, err := p.Rep.GetCoinPairs()
+	if err != nil {
+		return report, err
+	}
+	if len(opts.Symbols) != 0 {
+		pairs, report.Invalid = selectPairs(pairs, opts.Symbols)
+	}
+	bucket := time.Now().UTC().Truncate(p.Delay)
+	assets, coinAssets := requestAssets(pairs)
 
-		queryParam = prepareQueryParamForBatch(chunk)
+	// assets left without a quote by provider failures are reported as invalid,
+	// the quotes that were retrieved are still stored
+	quotes, unknown, fetchErr := p.fetchPrices(assets)
+	if fetchErr != nil {
+		p.Log.Error("Failed to retrieve prices", zap.Int("assets", len(assets)), zap.Int("retrieved", len(quotes)), zap.Error(fetchErr))
+	}
+	p.Log.Debug("Batch of prices retrieved", zap.Int("count", len(quotes)))
 
-		response, err := p.Client.GetBatchOfLastPrice(queryParam)
-		if err != nil {
-			p.Log.Sugar().Errorf("Failed to retrieve price for batch %v", chunk, zap.Error(err))
-			return err
+	prices := make(map[provider.Asset]provider.Quote, len(quotes))
+	for _, quote := range quotes {
+		prices[quote.Asset] = quote
+	}
+
+	// only coins whose own pair is unknown are quarantined, an unknown conversion pair
+	// leaves the coins of that quote without a USDT value
+	rejected := make(map[string]struct{}, len(unknown))
+	for _, asset := range unknown {
+		if _, ok := coinAssets[asset]; !ok {
+			p.Log.Warn("Conversion pair is not listed by providers", zap.Stringer("asset", asset))
+			continue
 		}
-		batchOfPrices = append(batchOfPrices, response...)
+		rejected[asset.Symbol] = struct{}{}
+		report.Quarantined = append(report.Quarantined, asset.Symbol)
+	}
+	if !opts.DryRun {
+		p.quarantine(report.Quarantined)
 	}
-	p.Log.Debug("Batch of prices retrieved", zap.Any("count", batchOfPrices))
+	rates := p.conversionRates(pairs, prices)
+	var stored []*postgres.CoinPair
 
 	//to do: make batch insert
-	for _, pair := range batchOfPrices {
-		price, err := utils.StringToDecimal(pair.Price)
-		if err != nil {
-			p.Log.Error("Failed to convert price to decimal", zap.Error(err))
-			return err
+	for _, pair := range pairs {
+		coin := pair.Ticker
+		if _, ok := rejected[coin]; ok {
+			continue
 		}
-		p.Log.Debug("Inserting hourly price", zap.String("symbol", pair.Symbol), zap.String("price", price.String()))
-		err = p.Rep.InsertPrice(&postgres.Price{
-			Fromsymbol: strings.TrimSuffix(pair.Symbol, "USDT"),
+		fetched, ok := prices[coinAsset(pair)]
+		if !ok {
+			report.Invalid = append(report.Invalid, coin)
+			continue
+		}
+		price := fetched.Price
+		if !price.IsPositive() {
+			p.Log.Warn("Non positive price received", zap.Stringer("asset", fetched.Asset), zap.String("price", price.String()))
+			report.Invalid = append(report.Invalid, coin)
+			continue
+		}
+
+		row := &postgres.Price{
+			Fromsymbol: coin,
 			Last_price: price.Truncate(8),
-			TS:         time.Now(),
-			Tosymbol:   USDT,
-		})
-		if err != nil {
-			p.Log.Error("Failed to insert hourly price", zap.Error(err))
-			return err
+			TS:         bucket,
+			SourceTS:   fetched.Time,
+			Tosymbol:   pair.QuoteAsset,
+			Sources:    quoteSources(fetched),
+		}
+		rows := []*postgres.Price{row}
+		if pair.QuoteAsset != USDT {
+			rate, ok := rates[pair.QuoteAsset]
+			if ok {
+				rows = append(rows, crossRate(row, rate))
+			} else {
+				p.Log.Warn("Coin is stored without USDT value, conversion rate is missing",
+					zap.String("coin", coin), zap.String("quote", pair.QuoteAsset))
+				report.Invalid = append(report.Invalid, coin)
+			}
+		}
+
+		for _, row := range rows {
+			report.Rows = append(report.Rows, newReportRow(row))
+			if opts.DryRun {
+				continue
+			}
+
+			p.Log.Debug("Inserting hourly price", zap.String("symbol", row.Fromsymbol+row.Tosymbol), zap.String("price", row.Last_price.String()))
+			err = p.Rep.InsertPrice(row)
+			if err != nil {
+				p.Log.Error("Failed to insert hourly price", zap.Error(err))
+				return report, err
+			}
+		}
+		if !opts.DryRun {
+			// 24hr stats come from binance, coins priced without it have none
+			if slices.Contains(quoteSources(fetched), binance.ProviderName) {
+				stored = append(stored, pair)
+			}
+			report.Processed++
 		}
 	}
-	return nil
+
+	if len(stored) != 0 {
+		p.storeTickerStats(stored, bucket)
+	}
+	return report, fetchErr
+}
+
+// alertOnBinanceLimits notifies admins when binance stopped serving us
+func (p JobParams) alertOnBinanceLimits(err error) {
+	if p.Alerts == nil {
+		return
+	}
+	switch {
+	case errors.Is(err, binance.ErrBanned):
+		p.Alerts.Notify(context.Background(), fmt.Sprintf("Binance banned our IP, hourly prices are not updated: %s", err))
+	case errors.Is(err, binance.ErrRateLimited):
+		p.Alerts.Notify(context.Background(), fmt.Sprintf("Binance rate limit reached, hourly prices are not updated: %s", err))
+	}
 }
 
 func prepareQueryParamForBatch(chunk []string) string {
-	var coinsToRequest []string
-	for _, ticker := range chunk {
-		coinsToRequest = append(coinsToRequest, fmt.Sprintf("\"%sUSDT\"", ticker))
+	var symbolsToRequest []string
+	for _, symbol := range chunk {
+		symbolsToRequest = append(symbolsToRequest, fmt.Sprintf("\"%s\"", symbol))
 	}
-	queryParam := strings.Join(coinsToRequest, ",")
+	queryParam := strings.Join(symbolsToRequest, ",")
 	queryParam = fmt.Sprintf("[%s]", queryParam)
 	return queryParam
 }
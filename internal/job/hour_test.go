@@ -0,0 +1,257 @@
+package job
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/config"
+	"github.com/zheka156/market_data/internal/integration/binance"
+	"github.com/zheka156/market_data/internal/integration/binance/binancetest"
+	"github.com/zheka156/market_data/internal/postgres"
+	"github.com/zheka156/market_data/internal/provider"
+	"go.uber.org/zap"
+)
+
+// fakeRepository keeps what the hourly job writes, methods the job doesn't use panic
+type fakeRepository struct {
+	postgres.Repository
+	pairs       []*postgres.CoinPair
+	prices      []*postgres.Price
+	stats       []*postgres.TickerStats
+	quarantined []string
+}
+
+func (r *fakeRepository) GetCoinPairs() ([]*postgres.CoinPair, error) {
+	return r.pairs, nil
+}
+
+func (r *fakeRepository) InsertPrice(price *postgres.Price) error {
+	r.prices = append(r.prices, price)
+	return nil
+}
+
+func (r *fakeRepository) InsertTickerStats(stats *postgres.TickerStats) error {
+	r.stats = append(r.stats, stats)
+	return nil
+}
+
+func (r *fakeRepository) QuarantineCoin(ticker string, reason string) error {
+	r.quarantined = append(r.quarantined, ticker)
+	return nil
+}
+
+func (r *fakeRepository) price(from string, to string) *postgres.Price {
+	for _, price := range r.prices {
+		if price.Fromsymbol == from && price.Tosymbol == to {
+			return price
+		}
+	}
+	return nil
+}
+
+func newTestJob(t *testing.T, pairs ...*postgres.CoinPair) (JobParams, *fakeRepository, *binancetest.Server) {
+	t.Helper()
+	fake, server := binancetest.NewServer()
+	t.Cleanup(server.Close)
+	t.Setenv("BINANCE_URL", server.URL)
+
+	logger := zap.NewNop()
+	client := binance.NewClient(logger, &config.Config{Binance: config.Binance{MaxRetries: 1, MaxRetryWait: time.Second}})
+	prices, err := provider.NewRegistry(logger, config.Providers{Classes: map[string][]string{"crypto": {binance.ProviderName}}},
+		binance.NewProvider(logger, client),
+	)
+	if err != nil {
+		t.Fatalf("failed to create registry: %s", err)
+	}
+	rep := &fakeRepository{pairs: pairs}
+	return *NewJobParams(logger, client, prices, rep, nil, nil, config.Jobs{}), rep, fake
+}
+
+func pair(ticker string, quote string) *postgres.CoinPair {
+	return &postgres.CoinPair{Ticker: ticker, QuoteAsset: quote}
+}
+
+func TestProcessStoresPrices(t *testing.T) {
+	params, rep, fake := newTestJob(t, pair("BTC", USDT), pair("ETH", USDT))
+	fake.SetPrice("BTC", USDT, "65000.12345678")
+	fake.SetPrice("ETH", USDT, "3200")
+
+	processed, err := params.Process()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if processed != 2 || len(rep.prices) != 2 {
+		t.Fatalf("expected 2 stored prices, got %d (%d rows)", processed, len(rep.prices))
+	}
+	btc := rep.price("BTC", USDT)
+	if btc == nil || !btc.Last_price.Equal(decimal.RequireFromString("65000.12345678")) {
+		t.Errorf("unexpected BTC price %+v", btc)
+	}
+	bucket := time.Now().UTC().Truncate(time.Hour)
+	if !btc.TS.Equal(bucket) || btc.DerivedFrom != nil {
+		t.Errorf("expected fetched price in bucket %s, got %+v", bucket, btc)
+	}
+	if len(rep.stats) != 2 {
+		t.Errorf("expected 24hr stats of 2 pairs, got %d", len(rep.stats))
+	}
+}
+
+func TestProcessBatchesSymbols(t *testing.T) {
+	var pairs []*postgres.CoinPair
+	params, rep, fake := newTestJob(t)
+	for i := 0; i < maxTickersPerBatch*2+5; i++ {
+		coin := string(rune('A'+i/26)) + string(rune('A'+i%26)) + "X"
+		pairs = append(pairs, pair(coin, USDT))
+		fake.SetPrice(coin, USDT, "1")
+	}
+	rep.pairs = pairs
+
+	processed, err := params.Process()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if processed != len(pairs) {
+		t.Errorf("expected %d stored prices, got %d", len(pairs), processed)
+	}
+	if requests := fake.Requests("/api/v3/ticker/price"); requests != 3 {
+		t.Errorf("expected 3 batches, got %d requests", requests)
+	}
+}
+
+func TestProcessQuarantinesInvalidSymbols(t *testing.T) {
+	params, rep, fake := newTestJob(t, pair("BTC", USDT), pair("DEAD", USDT), pair("ETH", USDT))
+	fake.SetPrice("BTC", USDT, "65000")
+	fake.SetPrice("ETH", USDT, "3200")
+
+	report, err := params.ProcessPrices(RunOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(rep.quarantined) != 1 || rep.quarantined[0] != "DEAD" {
+		t.Errorf("expected DEAD to be quarantined, got %v", rep.quarantined)
+	}
+	if report.Processed != 2 || rep.price("BTC", USDT) == nil || rep.price("ETH", USDT) == nil {
+		t.Errorf("expected valid coins to be stored, got %+v", report)
+	}
+}
+
+func TestProcessDerivesCrossRates(t *testing.T) {
+	params, rep, fake := newTestJob(t, pair("XYZ", "BTC"), pair("EURC", "EUR"))
+	fake.SetPrice("XYZ", "BTC", "0.001")
+	fake.SetPrice("BTC", USDT, "60000")
+	fake.SetPrice("EURC", "EUR", "1")
+
+	report, err := params.ProcessPrices(RunOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if raw := rep.price("XYZ", "BTC"); raw == nil || !raw.Last_price.Equal(decimal.RequireFromString("0.001")) {
+		t.Errorf("expected the fetched pair to be stored, got %+v", raw)
+	}
+	derived := rep.price("XYZ", USDT)
+	if derived == nil || !derived.Last_price.Equal(decimal.NewFromInt(60)) {
+		t.Fatalf("expected XYZ valued at 60 USDT, got %+v", derived)
+	}
+	if derived.DerivedFrom == nil || *derived.DerivedFrom != "BTC" {
+		t.Errorf("expected the USDT price to be derived from BTC, got %v", derived.DerivedFrom)
+	}
+
+	// EURUSDT is not listed, the coin is stored against EUR only
+	if rep.price("EURC", "EUR") == nil || rep.price("EURC", USDT) != nil {
+		t.Errorf("expected EURC stored in EUR only, got %+v", rep.prices)
+	}
+	if len(report.Invalid) != 1 || report.Invalid[0] != "EURC" {
+		t.Errorf("expected EURC reported without USDT value, got %v", report.Invalid)
+	}
+	if len(rep.quarantined) != 0 {
+		t.Errorf("a missing conversion pair should not quarantine coins, got %v", rep.quarantined)
+	}
+}
+
+func TestProcessDryRunWritesNothing(t *testing.T) {
+	params, rep, fake := newTestJob(t, pair("BTC", USDT), pair("DEAD", USDT))
+	fake.SetPrice("BTC", USDT, "65000")
+
+	report, err := params.ProcessPrices(RunOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(rep.prices) != 0 || len(rep.stats) != 0 || len(rep.quarantined) != 0 {
+		t.Errorf("dry run wrote %d prices, %d stats, quarantined %v", len(rep.prices), len(rep.stats), rep.quarantined)
+	}
+	if len(report.Rows) != 1 || len(report.Quarantined) != 1 || report.Processed != 0 {
+		t.Errorf("unexpected dry run report %+v", report)
+	}
+}
+
+func TestProcessSelectedSymbols(t *testing.T) {
+	params, rep, fake := newTestJob(t, pair("BTC", USDT), pair("ETH", USDT))
+	fake.SetPrice("BTC", USDT, "65000")
+	fake.SetPrice("ETH", USDT, "3200")
+
+	report, err := params.ProcessPrices(RunOptions{Symbols: []string{"ETH", "NOPE"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(rep.prices) != 1 || rep.price("ETH", USDT) == nil {
+		t.Errorf("expected only ETH to be stored, got %+v", rep.prices)
+	}
+	if len(report.Invalid) != 1 || report.Invalid[0] != "NOPE" {
+		t.Errorf("expected NOPE reported as invalid, got %v", report.Invalid)
+	}
+}
+
+func TestProcessFailsOnBan(t *testing.T) {
+	params, rep, fake := newTestJob(t, pair("BTC", USDT))
+	fake.SetPrice("BTC", USDT, "65000")
+	fake.Ban(1, 2*time.Minute)
+
+	_, err := params.Process()
+	if !errors.Is(err, binance.ErrBanned) {
+		t.Fatalf("expected ban error, got %v", err)
+	}
+	if len(rep.prices) != 0 {
+		t.Errorf("expected nothing stored, got %+v", rep.prices)
+	}
+}
+
+// downProvider fails every request
+type downProvider struct {
+	namedProvider
+}
+
+func (p downProvider) BatchLastPrice(assets []provider.Asset) ([]provider.Quote, []provider.Asset, error) {
+	return nil, nil, errors.New("provider is down")
+}
+
+func TestProcessStoresPricesDespiteFallbackFailure(t *testing.T) {
+	fake, server := binancetest.NewServer()
+	t.Cleanup(server.Close)
+	t.Setenv("BINANCE_URL", server.URL)
+	fake.SetPrice("BTC", USDT, "65000")
+
+	logger := zap.NewNop()
+	client := binance.NewClient(logger, &config.Config{Binance: config.Binance{MaxRetries: 1, MaxRetryWait: time.Second}})
+	prices, err := provider.NewRegistry(logger, config.Providers{Classes: map[string][]string{"crypto": {binance.ProviderName, "down"}}},
+		binance.NewProvider(logger, client), downProvider{namedProvider{name: "down"}},
+	)
+	if err != nil {
+		t.Fatalf("failed to create registry: %s", err)
+	}
+	rep := &fakeRepository{pairs: []*postgres.CoinPair{pair("BTC", USDT), pair("NEW", USDT)}}
+	params := *NewJobParams(logger, client, prices, rep, nil, nil, config.Jobs{})
+
+	report, err := params.ProcessPrices(RunOptions{})
+	if err == nil {
+		t.Fatal("expected the fallback failure")
+	}
+	if rep.price("BTC", USDT) == nil || report.Processed != 1 {
+		t.Errorf("expected BTC stored, got %+v", rep.prices)
+	}
+	if len(report.Invalid) != 1 || report.Invalid[0] != "NEW" || len(rep.quarantined) != 0 {
+		t.Errorf("expected NEW reported invalid and not quarantined, got %v and %v", report.Invalid, rep.quarantined)
+	}
+}
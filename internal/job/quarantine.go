@@ -0,0 +1,29 @@
+package job
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+const invalidSymbolReason = "not listed by price providers"
+
+// quarantine deactivates coins no price provider lists and alerts admins
+func (p JobParams) quarantine(coins []string) {
+	if len(coins) == 0 {
+		return
+	}
+	var quarantined []string
+	for _, coin := range coins {
+		if err := p.Rep.QuarantineCoin(coin, invalidSymbolReason); err != nil {
+			p.Log.Error("Failed to quarantine coin", zap.String("coin", coin), zap.Error(err))
+			continue
+		}
+		quarantined = append(quarantined, coin)
+	}
+	if p.Alerts != nil {
+		p.Alerts.Notify(context.Background(), fmt.Sprintf(
+			"Coins %v are not listed by price providers, quarantined: %v", coins, quarantined))
+	}
+}
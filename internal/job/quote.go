@@ -0,0 +1,98 @@
+package job
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/postgres"
+	"github.com/zheka156/market_data/internal/provider"
+	"go.uber.org/zap"
+)
+
+// coinAsset is the pair a coin is priced in
+func coinAsset(pair *postgres.CoinPair) provider.Asset {
+	return provider.Asset{Symbol: pair.Ticker, Quote: pair.QuoteAsset, Class: provider.Crypto, Source: pair.PriceSource}
+}
+
+// conversionAsset is the pair converting a quote asset to USDT
+func conversionAsset(quote string) provider.Asset {
+	return provider.Asset{Symbol: quote, Quote: USDT, Class: provider.Crypto}
+}
+
+// requestAssets returns the assets to price: the pair of every coin and the pairs
+// converting their quote assets to USDT. coins contains the pairs of the coins.
+func requestAssets(pairs []*postgres.CoinPair) (assets []provider.Asset, coins map[provider.Asset]struct{}) {
+	coins = make(map[provider.Asset]struct{}, len(pairs))
+	seen := make(map[provider.Asset]struct{}, len(pairs))
+	add := func(asset provider.Asset) {
+		if _, ok := seen[asset]; ok {
+			return
+		}
+		seen[asset] = struct{}{}
+		assets = append(assets, asset)
+	}
+	for _, pair := range pairs {
+		asset := coinAsset(pair)
+		coins[asset] = struct{}{}
+		add(asset)
+	}
+	for _, pair := range pairs {
+		if pair.QuoteAsset != USDT {
+			add(conversionAsset(pair.QuoteAsset))
+		}
+	}
+	return assets, coins
+}
+
+// conversionRates returns the USDT rate of every non USDT quote asset of pairs.
+// Quotes without a valid rate are missing from the result.
+func (p JobParams) conversionRates(pairs []*postgres.CoinPair, prices map[provider.Asset]provider.Quote) map[string]decimal.Decimal {
+	rates := make(map[string]decimal.Decimal)
+	for _, pair := range pairs {
+		quote := pair.QuoteAsset
+		if quote == USDT {
+			continue
+		}
+		if _, ok := rates[quote]; ok {
+			continue
+		}
+		fetched, ok := prices[conversionAsset(quote)]
+		if !ok {
+			continue
+		}
+		if !fetched.Price.IsPositive() {
+			p.Log.Warn("Invalid conversion rate received", zap.Stringer("asset", fetched.Asset), zap.String("price", fetched.Price.String()))
+			continue
+		}
+		rates[quote] = fetched.Price
+	}
+	return rates
+}
+
+// crossRate values a price quoted in another asset in USDT using the quote/USDT rate
+func crossRate(price *postgres.Price, rate decimal.Decimal) *postgres.Price {
+	quote := price.Tosymbol
+	return &postgres.Price{
+		Fromsymbol:  price.Fromsymbol,
+		Tosymbol:    USDT,
+		Last_price:  price.Last_price.Mul(rate).Truncate(8),
+		TS:          price.TS,
+		SourceTS:    price.SourceTS,
+		DerivedFrom: &quote,
+		Sources:     price.Sources,
+	}
+}
+
+// selectPairs keeps pairs of the requested coins, unknown coins are returned separately
+func selectPairs(pairs []*postgres.CoinPair, requested []string) (selected []*postgres.CoinPair, unknown []string) {
+	tickers := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		tickers = append(tickers, pair.Ticker)
+	}
+	found, unknown := selectSymbols(tickers, requested)
+	wanted := toSet(found)
+	for _, pair := range pairs {
+		if _, ok := wanted[pair.Ticker]; ok {
+			selected = append(selected, pair)
+		}
+	}
+	return selected, unknown
+}
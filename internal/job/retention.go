@@ -0,0 +1,48 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	RetentionJobName = "retention"
+
+	retentionInterval = 24 * time.Hour
+	// run after the midnight hourly prices are stored
+	retentionOffset = 15 * time.Minute
+)
+
+func RetentionJob(ctx context.Context, params JobParams) {
+	logger := params.Log
+
+	for waitNextRun(ctx, retentionInterval, retentionOffset) {
+		logger.Info("Retention job started")
+		err := params.trackRun(ctx, RetentionJobName, params.ApplyRetention)
+		if err != nil {
+			logger.Error("Failed to apply retention", zap.Error(err))
+			continue
+		}
+		logger.Info("Retention job stopped")
+	}
+}
+
+// ApplyRetention rolls hourly prices of days older than Retention into daily prices,
+// drops 24 hour stats of the same days and returns the number of removed hourly rows
+func (p JobParams) ApplyRetention() (int, error) {
+	cutoff := time.Now().UTC().Truncate(24 * time.Hour).Add(-p.Retention)
+	deleted, err := p.Rep.RollupHourlyPrices(cutoff)
+	if err != nil {
+		return 0, err
+	}
+	p.Log.Info("Hourly prices rolled up", zap.Time("cutoff", cutoff), zap.Int64("deleted", deleted))
+
+	statsDeleted, err := p.Rep.DeleteTickerStatsBefore(cutoff)
+	if err != nil {
+		return int(deleted), err
+	}
+	p.Log.Info("24hr stats removed", zap.Time("cutoff", cutoff), zap.Int64("deleted", statsDeleted))
+	return int(deleted), nil
+}
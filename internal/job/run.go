@@ -0,0 +1,94 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/zheka156/market_data/internal/postgres"
+	"go.uber.org/zap"
+)
+
+const lockReleaseTimeout = 5 * time.Second
+
+// ErrJobRunning is returned when the job is running on this or another replica
+var ErrJobRunning = errors.New("job is already running")
+
+// Lock keeps a job from running twice at once, e.g. a triggered run next to the scheduled one
+type Lock interface {
+	TryAcquire(ctx context.Context) (bool, error)
+	Release(ctx context.Context) error
+}
+
+// jobLockKey derives the advisory lock key of the job from its name
+func jobLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("job:" + name))
+	return int64(h.Sum64())
+}
+
+// acquire takes the lock of the job, without Locks every run is let through
+func (p JobParams) acquire(ctx context.Context, name string) (release func(), err error) {
+	if p.Locks == nil {
+		return func() {}, nil
+	}
+	lock := p.Locks(jobLockKey(name))
+	acquired, err := lock.TryAcquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return nil, fmt.Errorf("%s: %w", name, ErrJobRunning)
+	}
+	return func() {
+		releaseCtx, cancel := context.WithTimeout(context.Background(), lockReleaseTimeout)
+		defer cancel()
+		if err := lock.Release(releaseCtx); err != nil {
+			p.Log.Error("Failed to release job lock", zap.String("job", name), zap.Error(err))
+		}
+	}, nil
+}
+
+// trackRun executes fn under the lock of the job and records its outcome in job_runs.
+// Failing to write the history never stops the job itself.
+func (p JobParams) trackRun(ctx context.Context, jobName string, fn func() (int, error)) error {
+	release, err := p.acquire(ctx, jobName)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	run := p.startRun(jobName)
+	processed, jobErr := fn()
+	p.finishRun(run, processed, jobErr)
+	return jobErr
+}
+
+func (p JobParams) startRun(jobName string) *postgres.JobRun {
+	run := &postgres.JobRun{
+		JobName:   jobName,
+		StartedAt: time.Now().UTC(),
+		Status:    postgres.JobRunStatusRunning,
+	}
+	if err := p.Rep.CreateJobRun(run); err != nil {
+		p.Log.Error("Failed to create job run", zap.String("job", jobName), zap.Error(err))
+	}
+	return run
+}
+
+func (p JobParams) finishRun(run *postgres.JobRun, processed int, jobErr error) {
+	finishedAt := time.Now().UTC()
+	run.FinishedAt = &finishedAt
+	run.SymbolsProcessed = processed
+	run.Status = postgres.JobRunStatusSuccess
+	if jobErr != nil {
+		errText := jobErr.Error()
+		run.Status = postgres.JobRunStatusFailed
+		run.Error = &errText
+	}
+	if err := p.Rep.FinishJobRun(run); err != nil {
+		p.Log.Error("Failed to finish job run", zap.String("job", run.JobName), zap.Error(err))
+	}
+}
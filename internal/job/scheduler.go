@@ -0,0 +1,50 @@
+package job
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RunScheduled starts every scheduled job and blocks until all of them
+// are stopped by ctx. Only the elected leader replica should call it.
+func RunScheduled(ctx context.Context, params JobParams) {
+	jobs := []func(ctx context.Context, params JobParams){
+		HourJob,
+		RetentionJob,
+		StockJob,
+		CatalogJob,
+		FXRatesJob,
+	}
+
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			job(ctx, params)
+		}()
+	}
+	wg.Wait()
+	params.Log.Info("Scheduled jobs are stopped")
+}
+
+// waitNextRun sleeps until the next multiple of interval shifted by offset (UTC based).
+// Returns false if ctx is done before that.
+func waitNextRun(ctx context.Context, interval time.Duration, offset time.Duration) bool {
+	now := time.Now().UTC()
+	next := now.Truncate(interval).Add(offset)
+	if !next.After(now) {
+		next = next.Add(interval)
+	}
+
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,282 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/zheka156/market_data/internal/integration/polygon"
+	"github.com/zheka156/market_data/internal/postgres"
+	"github.com/zheka156/market_data/internal/provider"
+	"go.uber.org/zap"
+)
+
+const (
+	StockJobName = "stock_daily"
+
+	defaultStockLookbackDays = 5
+	stockInterval            = 24 * time.Hour
+	// polygon free tier serves the previous session after midnight ET
+	stockOffset = 6 * time.Hour
+	// stockPublicationDelay is the time after the end of the session date by which polygon has
+	// published its bars, a market without bars later than that was closed
+	stockPublicationDelay = 12 * time.Hour
+)
+
+func StockJob(ctx context.Context, params JobParams) {
+	logger := params.Log
+
+	for waitNextRun(ctx, stockInterval, stockOffset) {
+		logger.Info("Stock job started")
+		err := params.trackRun(ctx, StockJobName, func() (int, error) {
+			report, err := params.ProcessStocks(ctx, RunOptions{})
+			return report.Processed, err
+		})
+		if err != nil {
+			logger.Error("Failed to update stock prices", zap.Error(err))
+			continue
+		}
+		logger.Info("Stock job stopped")
+	}
+}
+
+// ProcessStocks stores daily bars of the selected watchlist tickers (all if none selected)
+// for the sessions missing in the lookback window, nothing is written on a dry run.
+// With polygon all tickers of a session are fetched in one grouped request.
+func (p JobParams) ProcessStocks(ctx context.Context, opts RunOptions) (*RunReport, error) {
+	report := &RunReport{Job: StockJobName, DryRun: opts.DryRun}
+
+	tickers, err := p.Rep.GetStockTickers()
+	if err != nil {
+		return report, err
+	}
+	if len(opts.Symbols) != 0 {
+		tickers, report.Invalid = selectSymbols(tickers, opts.Symbols)
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	firstDay := today.AddDate(0, 0, -p.Stock.LookbackDays)
+
+	if stocks, ok := p.groupedStocks(); ok {
+		return report, p.processGroupedStocks(ctx, stocks, tickers, firstDay, today, opts, report)
+	}
+
+	var failed []string
+	for _, ticker := range tickers {
+		from, err := p.firstMissingSession(ticker, firstDay)
+		if err != nil {
+			return report, err
+		}
+
+		for day := from; day.Before(today); day = day.AddDate(0, 0, 1) {
+			if isWeekend(day) {
+				continue
+			}
+			if !p.waitStockRequest(ctx) {
+				return report, ctx.Err()
+			}
+			price, err := p.fetchStockDay(ticker, day)
+			if err != nil {
+				p.Log.Error("Failed to fetch stock price", zap.String("ticker", ticker), zap.Time("day", day), zap.Error(err))
+				failed = append(failed, ticker)
+				break
+			}
+			if price == nil {
+				continue
+			}
+
+			report.Rows = append(report.Rows, ReportRow{
+				Symbol: price.Ticker,
+				Price:  price.Close,
+				TS:     price.SessionDate,
+			})
+			if opts.DryRun {
+				continue
+			}
+			if err := p.Rep.InsertStockPrice(price); err != nil {
+				return report, err
+			}
+			report.Processed++
+		}
+	}
+	if len(failed) != 0 {
+		report.Invalid = append(report.Invalid, failed...)
+		return report, fmt.Errorf("failed to update stocks %v", failed)
+	}
+	return report, nil
+}
+
+// processGroupedStocks fetches every session of the window missing for any ticker in one request.
+// Sessions are recorded with the tickers that are done, so a rerun doesn't request them again.
+// A ticker without a bar is done only once the session is published for sure, e.g. on a market
+// holiday, before that the session may be missing because polygon didn't publish it yet.
+func (p JobParams) processGroupedStocks(ctx context.Context, stocks *polygon.Provider, tickers []string, firstDay time.Time, today time.Time, opts RunOptions, report *RunReport) error {
+	from := make(map[string]time.Time, len(tickers))
+	for _, ticker := range tickers {
+		first, err := p.firstMissingSession(ticker, firstDay)
+		if err != nil {
+			return err
+		}
+		from[ticker] = first
+	}
+
+	var failed []string
+	for day := firstDay; day.Before(today); day = day.AddDate(0, 0, 1) {
+		if isWeekend(day) {
+			continue
+		}
+		var missing []string
+		for _, ticker := range tickers {
+			if !day.Before(from[ticker]) {
+				missing = append(missing, ticker)
+			}
+		}
+		session, err := p.Rep.GetStockSession(day)
+		if err != nil {
+			return err
+		}
+		if session != nil {
+			missing = slices.DeleteFunc(missing, func(ticker string) bool {
+				return slices.Contains(session.Tickers, ticker)
+			})
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		if !p.waitStockRequest(ctx) {
+			return ctx.Err()
+		}
+		published := time.Since(day.AddDate(0, 0, 1)) >= stockPublicationDelay
+		candles, err := stocks.GroupedDaily(day, missing)
+		if errors.Is(err, polygon.ErrNoData) {
+			p.Log.Info("No stock session", zap.Time("day", day), zap.Bool("published", published))
+		} else if err != nil {
+			report.Invalid = append(report.Invalid, missing...)
+			return fmt.Errorf("failed to get stock session %s: %w", day.Format(time.DateOnly), err)
+		}
+
+		requested := make([]string, 0, len(missing))
+		var prices []*postgres.StockPrice
+		for _, ticker := range missing {
+			candle, ok := candles[ticker]
+			if !ok {
+				if published {
+					requested = append(requested, ticker)
+				}
+				p.Log.Info("No stock bar", zap.String("ticker", ticker), zap.Time("day", day))
+				continue
+			}
+			price, err := newStockPrice(ticker, day, candle)
+			if err != nil {
+				p.Log.Error("Invalid stock price", zap.String("ticker", ticker), zap.Time("day", day), zap.Error(err))
+				failed = append(failed, ticker)
+				continue
+			}
+			requested = append(requested, ticker)
+			prices = append(prices, price)
+			report.Rows = append(report.Rows, ReportRow{
+				Symbol: price.Ticker,
+				Price:  price.Close,
+				TS:     price.SessionDate,
+			})
+		}
+		if opts.DryRun || len(requested) == 0 {
+			continue
+		}
+		session = &postgres.StockSession{
+			SessionDate: day,
+			Tickers:     requested,
+			Bars:        len(prices),
+			FetchedAt:   time.Now().UTC(),
+		}
+		if err := p.Rep.InsertStockSession(session, prices); err != nil {
+			return err
+		}
+		report.Processed += len(prices)
+	}
+	if len(failed) != 0 {
+		slices.Sort(failed)
+		failed = slices.Compact(failed)
+		report.Invalid = append(report.Invalid, failed...)
+		return fmt.Errorf("failed to update stocks %v", failed)
+	}
+	return nil
+}
+
+// groupedStocks returns polygon if it is the first provider of stocks
+func (p JobParams) groupedStocks() (*polygon.Provider, bool) {
+	providers := p.Prices.For(stockAsset(""))
+	if len(providers) == 0 {
+		return nil, false
+	}
+	stocks, ok := providers[0].(*polygon.Provider)
+	return stocks, ok
+}
+
+// firstMissingSession returns the day after the last stored session of the ticker,
+// not earlier than firstDay
+func (p JobParams) firstMissingSession(ticker string, firstDay time.Time) (time.Time, error) {
+	last, err := p.Rep.GetLastStockPrice(ticker)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if last != nil && !last.SessionDate.Before(firstDay) {
+		return last.SessionDate.AddDate(0, 0, 1), nil
+	}
+	return firstDay, nil
+}
+
+func isWeekend(day time.Time) bool {
+	return day.Weekday() == time.Saturday || day.Weekday() == time.Sunday
+}
+
+// fetchStockDay returns nil if there was no session on that day
+func (p JobParams) fetchStockDay(ticker string, day time.Time) (*postgres.StockPrice, error) {
+	candles, err := p.Prices.History(stockAsset(ticker), day, day, stockInterval)
+	if err != nil {
+		return nil, err
+	}
+	if len(candles) == 0 {
+		p.Log.Info("No stock session", zap.String("ticker", ticker), zap.Time("day", day))
+		return nil, nil
+	}
+	return newStockPrice(ticker, day, candles[0])
+}
+
+func newStockPrice(ticker string, day time.Time, candle provider.Candle) (*postgres.StockPrice, error) {
+	if !candle.Close.IsPositive() {
+		return nil, fmt.Errorf("non positive close price %s for %s", candle.Close, ticker)
+	}
+
+	return &postgres.StockPrice{
+		Ticker:      ticker,
+		SessionDate: day,
+		Open:        candle.Open,
+		High:        candle.High,
+		Low:         candle.Low,
+		Close:       candle.Close,
+		Volume:      candle.Volume,
+		UpdatedAt:   time.Now().UTC(),
+	}, nil
+}
+
+// stockAsset is a watchlist ticker priced in USD
+func stockAsset(ticker string) provider.Asset {
+	return provider.Asset{Symbol: ticker, Quote: "USD", Class: provider.Stock}
+}
+
+// waitStockRequest spaces polygon calls to stay within the rate limit
+func (p JobParams) waitStockRequest(ctx context.Context) bool {
+	if p.Stock.RequestInterval <= 0 {
+		return ctx.Err() == nil
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(p.Stock.RequestInterval):
+		return true
+	}
+}
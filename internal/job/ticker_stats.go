@@ -0,0 +1,63 @@
+package job
+
+import (
+	"time"
+
+	"github.com/zheka156/market_data/internal/integration/binance"
+	"github.com/zheka156/market_data/internal/postgres"
+	"go.uber.org/zap"
+)
+
+// storeTickerStats stores the 24 hour stats of the pairs in the hour bucket and returns the number of stored pairs.
+// Stats are supplementary to prices, failures are logged and don't fail the job.
+func (p JobParams) storeTickerStats(pairs []*postgres.CoinPair, bucket time.Time) int {
+	bySymbol := make(map[string]*postgres.CoinPair, len(pairs))
+	symbols := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		symbol := binance.Symbol(pair.Ticker, pair.QuoteAsset)
+		bySymbol[symbol] = pair
+		symbols = append(symbols, symbol)
+	}
+
+	stored := 0
+	for i := 0; i < len(symbols); i += maxTickersPerBatch {
+		end := min(i+maxTickersPerBatch, len(symbols))
+		chunk := symbols[i:end]
+
+		var response []binance.TickerStats
+		err := p.Prices.Track(binance.ProviderName, len(chunk), func() (err error) {
+			response, err = p.Client.GetBatchOf24hrStats(prepareQueryParamForBatch(chunk))
+			return err
+		})
+		if err != nil {
+			p.Log.Warn("Failed to retrieve 24hr stats for batch", zap.Strings("symbols", chunk), zap.Error(err))
+			continue
+		}
+		for _, stats := range response {
+			pair, ok := bySymbol[stats.Symbol]
+			if !ok {
+				continue
+			}
+			err = p.Rep.InsertTickerStats(&postgres.TickerStats{
+				Fromsymbol:         pair.Ticker,
+				Tosymbol:           pair.QuoteAsset,
+				TS:                 bucket,
+				PriceChange:        stats.PriceChange,
+				PriceChangePercent: stats.PriceChangePercent.Round(4),
+				High:               stats.HighPrice,
+				Low:                stats.LowPrice,
+				Volume:             stats.Volume,
+				QuoteVolume:        stats.QuoteVolume,
+				OpenTime:           stats.OpenTime,
+				CloseTime:          stats.CloseTime,
+			})
+			if err != nil {
+				p.Log.Error("Failed to insert 24hr stats", zap.String("symbol", stats.Symbol), zap.Error(err))
+				continue
+			}
+			stored++
+		}
+	}
+	p.Log.Debug("24hr stats stored", zap.Int("count", stored))
+	return stored
+}
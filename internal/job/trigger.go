@@ -0,0 +1,191 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/postgres"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrUnknownJob         = errors.New("unknown job")
+	ErrDryRunUnsupported  = errors.New("dry run is not supported by the job")
+	ErrSymbolsUnsupported = errors.New("symbols selection is not supported by the job")
+)
+
+type RunOptions struct {
+	// Symbols limits the run to these coins or tickers, empty means all
+	Symbols []string
+	// DryRun fetches and validates prices without writing them
+	DryRun bool
+}
+
+type RunReport struct {
+	Job       string      `json:"job"`
+	DryRun    bool        `json:"dry_run"`
+	Processed int         `json:"processed"`
+	Rows      []ReportRow `json:"rows"`
+	// Invalid contains symbols that are unknown or got no valid price
+	Invalid []string `json:"invalid,omitempty"`
+	// Quarantined contains symbols rejected by the exchange, on a dry run they are only detected
+	Quarantined []string `json:"quarantined,omitempty"`
+	// Added and Delisted are coin catalog changes
+	Added    []string `json:"added,omitempty"`
+	Delisted []string `json:"delisted,omitempty"`
+}
+
+// ReportRow is a price that was or would be written
+type ReportRow struct {
+	Symbol string          `json:"symbol"`
+	Quote  string          `json:"quote,omitempty"`
+	Price  decimal.Decimal `json:"price"`
+	TS     time.Time       `json:"ts"`
+	// Sources are the providers the price was computed from
+	Sources []string `json:"sources,omitempty"`
+}
+
+func newReportRow(price *postgres.Price) ReportRow {
+	return ReportRow{
+		Symbol:  price.Fromsymbol,
+		Quote:   price.Tosymbol,
+		Price:   price.Last_price,
+		TS:      price.TS,
+		Sources: price.Sources,
+	}
+}
+
+type trigger func(ctx context.Context, p JobParams, opts RunOptions) (*RunReport, error)
+
+// triggers are the jobs that can be run on demand
+var triggers = map[string]trigger{
+	HourJobName: func(ctx context.Context, p JobParams, opts RunOptions) (*RunReport, error) {
+		return p.ProcessPrices(opts)
+	},
+	StockJobName: func(ctx context.Context, p JobParams, opts RunOptions) (*RunReport, error) {
+		return p.ProcessStocks(ctx, opts)
+	},
+	CatalogJobName: func(ctx context.Context, p JobParams, opts RunOptions) (*RunReport, error) {
+		return p.SyncCatalog(opts)
+	},
+	FXRatesJobName: func(ctx context.Context, p JobParams, opts RunOptions) (*RunReport, error) {
+		report := &RunReport{Job: FXRatesJobName}
+		if p.FX == nil {
+			return report, fmt.Errorf("%s: fx rates service is not configured", FXRatesJobName)
+		}
+		processed, err := p.FX.Update()
+		report.Processed = processed
+		return report, err
+	},
+	RetentionJobName: func(ctx context.Context, p JobParams, opts RunOptions) (*RunReport, error) {
+		report := &RunReport{Job: RetentionJobName}
+		processed, err := p.ApplyRetention()
+		report.Processed = processed
+		return report, err
+	},
+}
+
+var (
+	// dryRunUnsupported are jobs that write without fetching anything to validate
+	dryRunUnsupported = map[string]bool{FXRatesJobName: true, RetentionJobName: true}
+	// symbolsUnsupported are jobs that always process everything
+	symbolsUnsupported = map[string]bool{FXRatesJobName: true, RetentionJobName: true, CatalogJobName: true}
+)
+
+// lookup returns the job of the name after checking that it supports the options
+func lookup(name string, opts RunOptions) (trigger, error) {
+	run, ok := triggers[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, ErrUnknownJob)
+	}
+	if opts.DryRun && dryRunUnsupported[name] {
+		return nil, fmt.Errorf("%s: %w", name, ErrDryRunUnsupported)
+	}
+	if len(opts.Symbols) != 0 && symbolsUnsupported[name] {
+		return nil, fmt.Errorf("%s: %w", name, ErrSymbolsUnsupported)
+	}
+	return run, nil
+}
+
+func JobNames() []string {
+	names := make([]string, 0, len(triggers))
+	for name := range triggers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Trigger runs the job on demand and waits for it. Real runs take the lock of the job
+// and are recorded in job_runs, dry runs are not.
+func (p JobParams) Trigger(ctx context.Context, name string, opts RunOptions) (*RunReport, error) {
+	run, err := lookup(name, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.DryRun {
+		return run(ctx, p, opts)
+	}
+
+	var report *RunReport
+	err = p.trackRun(ctx, name, func() (int, error) {
+		var err error
+		report, err = run(ctx, p, opts)
+		if report == nil {
+			return 0, err
+		}
+		return report.Processed, err
+	})
+	return report, err
+}
+
+// Start takes the lock of the job and runs it in the background, the returned run is in running
+// state and is finished in job_runs. ErrJobRunning is returned if the job is already running.
+func (p JobParams) Start(ctx context.Context, name string, opts RunOptions) (*postgres.JobRun, error) {
+	run, err := lookup(name, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.DryRun {
+		return nil, fmt.Errorf("%s: dry runs are not started in the background", name)
+	}
+	release, err := p.acquire(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	jobRun := p.startRun(name)
+	go func() {
+		defer release()
+		processed := 0
+		report, err := run(ctx, p, opts)
+		if report != nil {
+			processed = report.Processed
+		}
+		if err != nil {
+			p.Log.Error("Triggered job failed", zap.String("job", name), zap.Error(err))
+		}
+		p.finishRun(jobRun, processed, err)
+	}()
+	return jobRun, nil
+}
+
+// selectSymbols keeps requested symbols that are known, the rest is returned as unknown
+func selectSymbols(known []string, requested []string) (selected []string, unknown []string) {
+	knownSet := make(map[string]struct{}, len(known))
+	for _, symbol := range known {
+		knownSet[symbol] = struct{}{}
+	}
+	for _, symbol := range requested {
+		if _, ok := knownSet[symbol]; ok {
+			selected = append(selected, symbol)
+			continue
+		}
+		unknown = append(unknown, symbol)
+	}
+	return selected, unknown
+}
@@ -0,0 +1,38 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// heldLock is taken by another replica
+type heldLock struct{}
+
+func (heldLock) TryAcquire(ctx context.Context) (bool, error) {
+	return false, nil
+}
+
+func (heldLock) Release(ctx context.Context) error {
+	return nil
+}
+
+func TestTriggerRefusesRunningJob(t *testing.T) {
+	params, rep, _ := newTestJob(t, pair("BTC", USDT))
+	params.Locks = func(key int64) Lock {
+		return heldLock{}
+	}
+
+	if _, err := params.Trigger(context.Background(), HourJobName, RunOptions{}); !errors.Is(err, ErrJobRunning) {
+		t.Fatalf("expected the running job error, got %v", err)
+	}
+	if _, err := params.Start(context.Background(), HourJobName, RunOptions{}); !errors.Is(err, ErrJobRunning) {
+		t.Fatalf("expected the running job error, got %v", err)
+	}
+	if len(rep.prices) != 0 {
+		t.Errorf("expected nothing stored, got %d prices", len(rep.prices))
+	}
+	if _, err := params.Start(context.Background(), RetentionJobName, RunOptions{Symbols: []string{"BTC"}}); !errors.Is(err, ErrSymbolsUnsupported) {
+		t.Errorf("expected symbols to be rejected up front, got %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package leader
+
+import (
+	"context"
+	"time"
+
+	"github.com/zheka156/market_data/internal/config"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultRetryInterval = 30 * time.Second
+	defaultRenewInterval = 10 * time.Second
+	releaseTimeout       = 5 * time.Second
+)
+
+type Lock interface {
+	TryAcquire(ctx context.Context) (bool, error)
+	Renew(ctx context.Context) error
+	Release(ctx context.Context) error
+}
+
+// Elector makes sure only one replica runs the leader workload at a time.
+// Replicas that lost the election keep retrying and take over when the leader is gone.
+type Elector struct {
+	lock          Lock
+	log           *zap.Logger
+	retryInterval time.Duration
+	renewInterval time.Duration
+}
+
+func NewElector(logger *zap.Logger, lock Lock, conf config.Leader) *Elector {
+	e := &Elector{
+		lock:          lock,
+		log:           logger,
+		retryInterval: conf.RetryInterval,
+		renewInterval: conf.RenewInterval,
+	}
+	if e.retryInterval <= 0 {
+		e.retryInterval = defaultRetryInterval
+	}
+	if e.renewInterval <= 0 {
+		e.renewInterval = defaultRenewInterval
+	}
+	return e
+}
+
+// Run blocks until ctx is done. While this replica is the leader
+// lead is executed, its context is cancelled once leadership is lost.
+func (e *Elector) Run(ctx context.Context, lead func(ctx context.Context)) {
+	for {
+		acquired, err := e.lock.TryAcquire(ctx)
+		if err != nil {
+			e.log.Error("Failed to acquire leadership", zap.Error(err))
+		}
+		if acquired {
+			e.log.Info("Leadership acquired")
+			e.lead(ctx, lead)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(e.retryInterval):
+		}
+	}
+}
+
+func (e *Elector) lead(ctx context.Context, lead func(ctx context.Context)) {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		lead(leaderCtx)
+	}()
+
+	renew := time.NewTicker(e.renewInterval)
+	defer renew.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			cancel()
+			<-done
+			e.release()
+			return
+		case <-done:
+			e.log.Warn("Leader workload stopped, giving up leadership")
+			e.release()
+			return
+		case <-renew.C:
+			if err := e.lock.Renew(ctx); err != nil {
+				e.log.Error("Leadership lost", zap.Error(err))
+				cancel()
+				<-done
+				e.release()
+				return
+			}
+		}
+	}
+}
+
+func (e *Elector) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+	if err := e.lock.Release(ctx); err != nil {
+		e.log.Error("Failed to release leadership", zap.Error(err))
+		return
+	}
+	e.log.Info("Leadership released")
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"os"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// AdminAuth lets through requests carrying the ADMIN_TOKEN bearer token.
+// Without a configured token every request is refused.
+func AdminAuth(logger *zap.Logger) fiber.Handler {
+	token := os.Getenv("ADMIN_TOKEN")
+	if token == "" {
+		logger.Warn("ADMIN_TOKEN is not set, admin routes are disabled")
+	}
+	return func(c *fiber.Ctx) error {
+		if token == "" {
+			return c.SendStatus(fiber.StatusServiceUnavailable)
+		}
+		sent, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+			logger.Warn("Unauthorized admin request", zap.String("path", c.Path()), zap.String("ip", c.IP()))
+			return c.SendStatus(fiber.StatusUnauthorized)
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,193 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type QuarantinedCoin struct {
+	Ticker           string    `db:"ticker" name:"ticker"`
+	QuarantinedAt    time.Time `db:"quarantined_at" name:"quarantined_at"`
+	QuarantineReason string    `db:"quarantine_reason" name:"quarantine_reason"`
+}
+
+// CatalogCoin is a coin as it is synced from the exchange.
+// PriceSource is the provider a coin is pinned to, empty for exchange coins.
+type CatalogCoin struct {
+	Ticker        string `db:"ticker" name:"ticker"`
+	Name          string `db:"name" name:"name"`
+	Precision     int    `db:"precision" name:"precision"`
+	StepPrecision int    `db:"step_precision" name:"step_precision"`
+	QuoteAsset    string `db:"quote_asset" name:"quote_asset"`
+	IsTradeable   bool   `db:"is_tradeable" name:"is_tradeable"`
+	IsVisible     bool   `db:"is_visible" name:"is_visible"`
+	// IsDenied hides the coin even if it was visible before, it is not stored
+	IsDenied    bool   `db:"is_denied" name:"is_denied"`
+	PriceSource string `db:"price_source" name:"price_source"`
+}
+
+func (c *client) GetCatalogCoins() ([]*CatalogCoin, error) {
+	var coins []*CatalogCoin
+	query := `
+		SELECT ticker, COALESCE(name, ticker) AS name, COALESCE(precision, 8) AS precision,
+			step_precision, quote_asset, is_tradeable, is_visible, COALESCE(price_source, '') AS price_source
+		FROM coin
+		ORDER BY ticker
+	`
+	err := c.Select(&coins, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coin catalog: %w", err)
+	}
+	return coins, nil
+}
+
+// SyncCoinCatalog upserts the tradeable coins and marks every other exchange coin as not tradeable.
+// A visible coin stays visible unless it is denied, so new coins are only shown once they are allowed.
+// A coin pinned to another price source returns to the exchange once it is listed there,
+// unless the catalog pins it again.
+func (c *client) SyncCoinCatalog(coins []*CatalogCoin) error {
+	upsertQuery := `
+		INSERT INTO coin (ticker, name, precision, step_precision, quote_asset, is_tradeable, is_visible, price_source, synced_at)
+		VALUES (:ticker, :name, :precision, :step_precision, :quote_asset, TRUE, :is_visible, NULLIF(:price_source, ''), NOW())
+		ON CONFLICT (ticker) DO UPDATE SET
+			precision = EXCLUDED.precision,
+			step_precision = EXCLUDED.step_precision,
+			quote_asset = EXCLUDED.quote_asset,
+			is_tradeable = TRUE,
+			is_visible = (EXCLUDED.is_visible OR coin.is_visible) AND NOT :is_denied,
+			price_source = EXCLUDED.price_source,
+			synced_at = EXCLUDED.synced_at;
+	`
+	delistQuery := `
+		UPDATE coin SET
+			is_tradeable = FALSE,
+			synced_at = NOW()
+		WHERE is_tradeable AND price_source IS NULL AND NOT (ticker = ANY($1))
+	`
+	tickers := make([]string, 0, len(coins))
+	for _, coin := range coins {
+		tickers = append(tickers, coin.Ticker)
+	}
+
+	return c.SafeTx(func(tx *sqlx.Tx) error {
+		for _, coin := range coins {
+			_, err := tx.NamedExec(upsertQuery, coin)
+			if err != nil {
+				return fmt.Errorf("failed to sync coin %s: %w", coin.Ticker, err)
+			}
+		}
+		_, err := tx.Exec(delistQuery, pq.Array(tickers))
+		if err != nil {
+			return fmt.Errorf("failed to mark delisted coins: %w", err)
+		}
+		return nil
+	})
+}
+
+// QuarantineCoin deactivates the coin so jobs and the bot skip it
+func (c *client) QuarantineCoin(ticker string, reason string) error {
+	query := `
+		UPDATE coin SET
+			is_active = FALSE,
+			quarantined_at = NOW(),
+			quarantine_reason = $2
+		WHERE ticker = $1
+	`
+	return c.SafeTx(func(tx *sqlx.Tx) error {
+		_, err := tx.Exec(query, ticker, reason)
+		if err != nil {
+			return fmt.Errorf("failed to quarantine coin %s: %w", ticker, err)
+		}
+		return nil
+	})
+}
+
+func (c *client) ActivateCoin(ticker string) error {
+	query := `
+		UPDATE coin SET
+			is_active = TRUE,
+			quarantined_at = NULL,
+			quarantine_reason = NULL
+		WHERE ticker = $1
+	`
+	return c.SafeTx(func(tx *sqlx.Tx) error {
+		_, err := tx.Exec(query, ticker)
+		if err != nil {
+			return fmt.Errorf("failed to activate coin %s: %w", ticker, err)
+		}
+		return nil
+	})
+}
+
+// PinCoin adds the coin priced from the source and reactivates it if it was quarantined.
+// A coin traded on the exchange is left as it is, false is returned then.
+func (c *client) PinCoin(ticker string, quoteAsset string, source string) (bool, error) {
+	query := `
+		INSERT INTO coin (ticker, name, precision, step_precision, quote_asset, is_tradeable, is_visible, price_source, synced_at)
+		VALUES ($1, $1, 8, 8, $2, TRUE, TRUE, $3, NOW())
+		ON CONFLICT (ticker) DO UPDATE SET
+			quote_asset = EXCLUDED.quote_asset,
+			is_tradeable = TRUE,
+			is_active = TRUE,
+			quarantined_at = NULL,
+			quarantine_reason = NULL,
+			price_source = EXCLUDED.price_source,
+			synced_at = EXCLUDED.synced_at
+		WHERE NOT coin.is_tradeable OR coin.price_source IS NOT NULL;
+	`
+	pinned := false
+	err := c.SafeTx(func(tx *sqlx.Tx) error {
+		result, err := tx.Exec(query, ticker, quoteAsset, source)
+		if err != nil {
+			return fmt.Errorf("failed to pin coin %s to %s: %w", ticker, source, err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to pin coin %s to %s: %w", ticker, source, err)
+		}
+		pinned = rows != 0
+		return nil
+	})
+	return pinned, err
+}
+
+func (c *client) GetQuarantinedCoins() ([]*QuarantinedCoin, error) {
+	var coins []*QuarantinedCoin
+	query := `
+		SELECT ticker, quarantined_at, COALESCE(quarantine_reason, '') AS quarantine_reason
+		FROM coin
+		WHERE quarantined_at IS NOT NULL
+		ORDER BY quarantined_at DESC
+	`
+	err := c.Select(&coins, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quarantined coins: %w", err)
+	}
+	return coins, nil
+}
+
+// CoinPair is a coin with the quote asset it is priced against.
+// PriceSource pins the provider of the coin, empty means the configured providers.
+type CoinPair struct {
+	Ticker      string `db:"ticker" name:"ticker"`
+	QuoteAsset  string `db:"quote_asset" name:"quote_asset"`
+	PriceSource string `db:"price_source" name:"price_source"`
+}
+
+func (c *client) GetCoinPairs() ([]*CoinPair, error) {
+	var pairs []*CoinPair
+	query := `
+		SELECT ticker, quote_asset, COALESCE(price_source, '') AS price_source
+		FROM coin
+		WHERE is_active AND is_tradeable AND is_visible
+		ORDER BY ticker
+	`
+	err := c.Select(&pairs, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coin pairs: %w", err)
+	}
+	return pairs, nil
+}
@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// CoinGeckoCoin maps our ticker to a coingecko coin ID, symbols are not unique on coingecko
+type CoinGeckoCoin struct {
+	Ticker      string    `db:"ticker" name:"ticker"`
+	CoinGeckoID string    `db:"coingecko_id" name:"coingecko_id"`
+	Name        string    `db:"name" name:"name"`
+	CreatedAt   time.Time `db:"created_at" name:"created_at"`
+}
+
+func (c *client) GetCoinGeckoCoins() ([]*CoinGeckoCoin, error) {
+	var coins []*CoinGeckoCoin
+	query := `SELECT ticker, coingecko_id, name, created_at FROM coingecko_coin ORDER BY ticker`
+	err := c.Select(&coins, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coingecko coins: %w", err)
+	}
+	return coins, nil
+}
+
+// GetCoinGeckoIDs returns coingecko IDs by ticker
+func (c *client) GetCoinGeckoIDs() (map[string]string, error) {
+	coins, err := c.GetCoinGeckoCoins()
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]string, len(coins))
+	for _, coin := range coins {
+		ids[coin.Ticker] = coin.CoinGeckoID
+	}
+	return ids, nil
+}
+
+// AddCoinGeckoCoin stores the mapping and adds the coin priced from coingecko.
+// A coin traded on the exchange keeps its exchange pair, a delisted one is moved to coingecko
+// and reactivated if it was quarantined.
+func (c *client) AddCoinGeckoCoin(coin *CoinGeckoCoin) error {
+	mappingQuery := `
+		INSERT INTO coingecko_coin (ticker, coingecko_id, name, created_at)
+		VALUES (:ticker, :coingecko_id, :name, NOW())
+		ON CONFLICT (ticker) DO UPDATE SET
+			coingecko_id = EXCLUDED.coingecko_id,
+			name = EXCLUDED.name;
+	`
+	coinQuery := `
+		INSERT INTO coin (ticker, name, precision, step_precision, quote_asset, is_tradeable, is_visible, price_source, synced_at)
+		VALUES (:ticker, :name, 8, 8, 'USDT', TRUE, TRUE, 'coingecko', NOW())
+		ON CONFLICT (ticker) DO UPDATE SET
+			quote_asset = EXCLUDED.quote_asset,
+			is_tradeable = TRUE,
+			is_active = TRUE,
+			quarantined_at = NULL,
+			quarantine_reason = NULL,
+			price_source = EXCLUDED.price_source,
+			synced_at = EXCLUDED.synced_at
+		WHERE NOT coin.is_tradeable OR coin.price_source IS NOT NULL;
+	`
+	return c.SafeTx(func(tx *sqlx.Tx) error {
+		_, err := tx.NamedExec(mappingQuery, coin)
+		if err != nil {
+			return fmt.Errorf("failed to map coin %s to coingecko %s: %w", coin.Ticker, coin.CoinGeckoID, err)
+		}
+		_, err = tx.NamedExec(coinQuery, coin)
+		if err != nil {
+			return fmt.Errorf("failed to add coingecko coin %s: %w", coin.Ticker, err)
+		}
+		return nil
+	})
+}
+
+// RemoveCoinGeckoCoin deletes the mapping, a coin priced from coingecko is marked as not tradeable
+func (c *client) RemoveCoinGeckoCoin(ticker string) error {
+	mappingQuery := `DELETE FROM coingecko_coin WHERE ticker = $1`
+	coinQuery := `
+		UPDATE coin SET
+			is_tradeable = FALSE,
+			synced_at = NOW()
+		WHERE ticker = $1 AND price_source = 'coingecko'
+	`
+	return c.SafeTx(func(tx *sqlx.Tx) error {
+		_, err := tx.Exec(mappingQuery, ticker)
+		if err != nil {
+			return fmt.Errorf("failed to remove coingecko mapping of %s: %w", ticker, err)
+		}
+		_, err = tx.Exec(coinQuery, ticker)
+		if err != nil {
+			return fmt.Errorf("failed to remove coingecko coin %s: %w", ticker, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+// FXRate is the amount of Currency per one USD on Date
+type FXRate struct {
+	Currency string          `db:"currency" name:"currency"`
+	Date     time.Time       `db:"date" name:"date"`
+	Rate     decimal.Decimal `db:"rate" name:"rate"`
+	Source   string          `db:"source" name:"source"`
+}
+
+func (c *client) InsertFXRates(rates []*FXRate) error {
+	query := `
+		INSERT INTO fx_rate (currency, date, rate, source, created_at)
+		VALUES (:currency, :date, :rate, :source, NOW())
+		ON CONFLICT (currency, date) DO UPDATE SET
+			rate = EXCLUDED.rate,
+			source = EXCLUDED.source,
+			created_at = NOW();
+	`
+	return c.SafeTx(func(tx *sqlx.Tx) error {
+		for _, rate := range rates {
+			_, err := tx.NamedExec(query, rate)
+			if err != nil {
+				return fmt.Errorf("failed to insert %s rate of %s: %w", rate.Currency, rate.Date.Format(time.DateOnly), err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetFXRate returns the newest rate of the currency on or before the date, nil if there is none
+func (c *client) GetFXRate(currency string, date time.Time) (*FXRate, error) {
+	var rates []*FXRate
+	query := `
+		SELECT currency, date, rate, source
+		FROM fx_rate
+		WHERE currency = $1 AND date <= $2
+		ORDER BY date DESC LIMIT 1
+	`
+	err := c.Select(&rates, query, currency, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s rate of %s: %w", currency, date.Format(time.DateOnly), err)
+	}
+	if len(rates) == 0 {
+		return nil, nil
+	}
+	return rates[0], nil
+}
+
+// GetChatCurrency returns the currency the chat values coins in, empty if it wasn't chosen
+func (c *client) GetChatCurrency(chatID string) (string, error) {
+	var currencies []string
+	query := `SELECT COALESCE(currency, '') FROM tg_chat WHERE chatId = $1`
+	err := c.Select(&currencies, query, chatID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get currency of chat %s: %w", chatID, err)
+	}
+	if len(currencies) == 0 {
+		return "", nil
+	}
+	return currencies[0], nil
+}
+
+// SetChatCurrency creates the chat if needed, an empty currency resets it to USDT
+func (c *client) SetChatCurrency(chatID string, currency string) error {
+	query := `
+		INSERT INTO tg_chat (chatId, created_at, updated_at, prime, is_active, currency)
+		VALUES ($1, NOW(), NOW(), FALSE, TRUE, NULLIF($2, ''))
+		ON CONFLICT (chatId) DO UPDATE SET
+			currency = EXCLUDED.currency,
+			updated_at = NOW();
+	`
+	return c.SafeTx(func(tx *sqlx.Tx) error {
+		_, err := tx.Exec(query, chatID, currency)
+		if err != nil {
+			return fmt.Errorf("failed to set currency of chat %s: %w", chatID, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// RollupHourlyPrices aggregates hourly rows older than cutoff into daily OHLC rows
+// and deletes them in the same transaction. A day rolled up again, e.g. after a backfill,
+// takes its open and close from the recomputed rows. Returns the number of deleted hourly rows.
+func (c *client) RollupHourlyPrices(cutoff time.Time) (int64, error) {
+	rollupQuery := `
+		INSERT INTO one_day_price (fromsym, tosym, ts, open, high, low, close, samples)
+		SELECT
+			fromsym,
+			tosym,
+			date_trunc('day', ts) AS day,
+			(array_agg(last_price ORDER BY ts ASC))[1],
+			MAX(last_price),
+			MIN(last_price),
+			(array_agg(last_price ORDER BY ts DESC))[1],
+			COUNT(*)
+		FROM one_hour_price
+		WHERE ts < $1
+		GROUP BY fromsym, tosym, day
+		ON CONFLICT (fromsym, tosym, ts) DO UPDATE SET
+			open = EXCLUDED.open,
+			high = GREATEST(one_day_price.high, EXCLUDED.high),
+			low = LEAST(one_day_price.low, EXCLUDED.low),
+			close = EXCLUDED.close,
+			samples = one_day_price.samples + EXCLUDED.samples;
+	`
+	deleteQuery := `DELETE FROM one_hour_price WHERE ts < $1`
+
+	var deleted int64
+	err := c.SafeTx(func(tx *sqlx.Tx) error {
+		_, err := tx.Exec(rollupQuery, cutoff)
+		if err != nil {
+			return fmt.Errorf("failed to rollup hourly prices before %s: %w", cutoff, err)
+		}
+		res, err := tx.Exec(deleteQuery, cutoff)
+		if err != nil {
+			return fmt.Errorf("failed to delete hourly prices before %s: %w", cutoff, err)
+		}
+		deleted, err = res.RowsAffected()
+		return err
+	})
+	return deleted, err
+}
+
+// GetPriceHistory returns prices of the symbol in the quote asset in [from, to) ordered by time.
+// Hourly rows are used where they are kept, daily close prices for older days,
+// including the day from falls in.
+func (c *client) GetPriceHistory(symbol string, quote string, from time.Time, to time.Time) ([]*Price, error) {
+	var prices []*Price
+	query := `
+		SELECT fromsym, tosym, last_price, ts, source_ts
+		FROM one_hour_price
+		WHERE fromsym = $1 AND tosym = $2 AND ts >= $3 AND ts < $4
+		UNION ALL
+		SELECT d.fromsym, d.tosym, d.close AS last_price, d.ts, d.ts AS source_ts
+		FROM one_day_price d
+		WHERE d.fromsym = $1 AND d.tosym = $2 AND d.ts >= date_trunc('day', $3::timestamp) AND d.ts < $4
+			AND NOT EXISTS (
+				SELECT 1 FROM one_hour_price h
+				WHERE h.fromsym = d.fromsym AND h.tosym = d.tosym
+					AND h.ts >= d.ts AND h.ts < d.ts + INTERVAL '1 day'
+			)
+		ORDER BY ts
+	`
+	err := c.Select(&prices, query, symbol, quote, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price history for symbol %s/%s: %w", symbol, quote, err)
+	}
+	return prices, nil
+}
@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	JobRunStatusRunning = "running"
+	JobRunStatusSuccess = "success"
+	JobRunStatusFailed  = "failed"
+)
+
+type JobRun struct {
+	ID               uuid.UUID  `db:"id" name:"id"`
+	JobName          string     `db:"job_name" name:"job_name"`
+	StartedAt        time.Time  `db:"started_at" name:"started_at"`
+	FinishedAt       *time.Time `db:"finished_at" name:"finished_at"`
+	Status           string     `db:"status" name:"status"`
+	SymbolsProcessed int        `db:"symbols_processed" name:"symbols_processed"`
+	Error            *string    `db:"error" name:"error"`
+}
+
+// CreateJobRun stores a new run in running state, the id is generated if empty
+func (c *client) CreateJobRun(run *JobRun) error {
+	if run.ID == uuid.Nil {
+		run.ID = uuid.New()
+	}
+	if run.Status == "" {
+		run.Status = JobRunStatusRunning
+	}
+	query := `
+		INSERT INTO job_runs (id, job_name, started_at, finished_at, status, symbols_processed, error)
+		VALUES (:id, :job_name, :started_at, :finished_at, :status, :symbols_processed, :error);
+	`
+	return c.SafeTx(func(tx *sqlx.Tx) error {
+		_, err := tx.NamedExec(query, run)
+		if err != nil {
+			return fmt.Errorf("failed to create job run for %s: %w", run.JobName, err)
+		}
+		return nil
+	})
+}
+
+func (c *client) FinishJobRun(run *JobRun) error {
+	query := `
+		UPDATE job_runs SET
+			finished_at = :finished_at,
+			status = :status,
+			symbols_processed = :symbols_processed,
+			error = :error
+		WHERE id = :id;
+	`
+	return c.SafeTx(func(tx *sqlx.Tx) error {
+		_, err := tx.NamedExec(query, run)
+		if err != nil {
+			return fmt.Errorf("failed to finish job run %s for %s: %w", run.ID, run.JobName, err)
+		}
+		return nil
+	})
+}
+
+// GetLastJobRuns returns the latest run of every job
+func (c *client) GetLastJobRuns() ([]*JobRun, error) {
+	var runs []*JobRun
+	query := `
+		SELECT DISTINCT ON (job_name) id, job_name, started_at, finished_at, status, symbols_processed, error
+		FROM job_runs
+		ORDER BY job_name, started_at DESC
+	`
+	err := c.Select(&runs, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last job runs: %w", err)
+	}
+	return runs, nil
+}
+
+func (c *client) GetJobRuns(jobName string, limit int) ([]*JobRun, error) {
+	var runs []*JobRun
+	query := `
+		SELECT id, job_name, started_at, finished_at, status, symbols_processed, error
+		FROM job_runs
+		WHERE job_name = $1
+		ORDER BY started_at DESC
+		LIMIT $2
+	`
+	err := c.Select(&runs, query, jobName, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get runs for job %s: %w", jobName, err)
+	}
+	return runs, nil
+}
@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// AdvisoryLock is a session level postgres advisory lock.
+// The lock lives as long as the dedicated connection holding it.
+type AdvisoryLock struct {
+	db   *sqlx.DB
+	key  int64
+	conn *sql.Conn
+}
+
+func (c *client) NewAdvisoryLock(key int64) *AdvisoryLock {
+	return &AdvisoryLock{db: c.DB, key: key}
+}
+
+// TryAcquire takes the lock without waiting, returns false if another session holds it
+func (l *AdvisoryLock) TryAcquire(ctx context.Context) (bool, error) {
+	if l.conn == nil {
+		conn, err := l.db.Conn(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to get connection for advisory lock %d: %w", l.key, err)
+		}
+		l.conn = conn
+	}
+
+	var acquired bool
+	err := l.conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, l.key).Scan(&acquired)
+	if err != nil {
+		l.closeConn()
+		return false, fmt.Errorf("failed to acquire advisory lock %d: %w", l.key, err)
+	}
+	if !acquired {
+		l.closeConn()
+	}
+	return acquired, nil
+}
+
+// Renew checks that the session is alive and still owns the lock
+func (l *AdvisoryLock) Renew(ctx context.Context) error {
+	if l.conn == nil {
+		return fmt.Errorf("advisory lock %d is not held", l.key)
+	}
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM pg_locks
+			WHERE locktype = 'advisory'
+				AND classid = ($1::bigint >> 32)::oid
+				AND objid = ($1::bigint & 4294967295)::oid
+				AND objsubid = 1
+				AND pid = pg_backend_pid()
+				AND granted
+		)
+	`
+	var held bool
+	err := l.conn.QueryRowContext(ctx, query, l.key).Scan(&held)
+	if err != nil {
+		l.closeConn()
+		return fmt.Errorf("failed to renew advisory lock %d: %w", l.key, err)
+	}
+	if !held {
+		l.closeConn()
+		return fmt.Errorf("advisory lock %d is lost", l.key)
+	}
+	return nil
+}
+
+func (l *AdvisoryLock) Release(ctx context.Context) error {
+	if l.conn == nil {
+		return nil
+	}
+	defer l.closeConn()
+	_, err := l.conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, l.key)
+	if err != nil {
+		return fmt.Errorf("failed to release advisory lock %d: %w", l.key, err)
+	}
+	return nil
+}
+
+func (l *AdvisoryLock) closeConn() {
+	if l.conn != nil {
+		l.conn.Close()
+		l.conn = nil
+	}
+}
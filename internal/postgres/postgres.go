@@ -7,21 +7,55 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
 type Repository interface {
 	InsertPrice(price *Price) error
-	GetLastHourPriceBySymbol(symbol string) (price *Price, err error)
+	GetLastHourPriceBySymbol(symbol string, quote string) (price *Price, err error)
+	GetLatestPriceTime() (*time.Time, error)
+	GetPriceHistory(symbol string, quote string, from time.Time, to time.Time) ([]*Price, error)
+	RollupHourlyPrices(cutoff time.Time) (int64, error)
+	InsertTickerStats(stats *TickerStats) error
+	GetLastTickerStats(symbol string) (*TickerStats, error)
+	DeleteTickerStatsBefore(cutoff time.Time) (int64, error)
 	GetTickers() ([]string, error)
+	GetCoinPairs() ([]*CoinPair, error)
+	QuarantineCoin(ticker string, reason string) error
+	ActivateCoin(ticker string) error
+	PinCoin(ticker string, quoteAsset string, source string) (bool, error)
+	GetQuarantinedCoins() ([]*QuarantinedCoin, error)
+	GetCatalogCoins() ([]*CatalogCoin, error)
+	SyncCoinCatalog(coins []*CatalogCoin) error
+	GetCoinGeckoCoins() ([]*CoinGeckoCoin, error)
+	GetCoinGeckoIDs() (map[string]string, error)
+	AddCoinGeckoCoin(coin *CoinGeckoCoin) error
+	RemoveCoinGeckoCoin(ticker string) error
+	InsertFXRates(rates []*FXRate) error
+	GetFXRate(currency string, date time.Time) (*FXRate, error)
 	CreateChat(chatID string) error
+	GetChatCurrency(chatID string) (string, error)
+	SetChatCurrency(chatID string, currency string) error
 	CreateChatCoins(chatID string, coin string, quantity decimal.Decimal) error
 	GetChatCoinInfo(chatID string) ([]*CoinInfo, error)
 	GetTicker(inputTicker string) (foundTicker string, err error)
 	AddTickerWithQuantityToChat(chatID string, coin string, quantity decimal.Decimal) error
 	RemoveCoinFromChat(chatID string, coin string) error
+	CreateJobRun(run *JobRun) error
+	FinishJobRun(run *JobRun) error
+	GetLastJobRuns() ([]*JobRun, error)
+	GetJobRuns(jobName string, limit int) ([]*JobRun, error)
+	NewAdvisoryLock(key int64) *AdvisoryLock
+	GetStockTickers() ([]string, error)
+	AddStock(ticker string) error
+	RemoveStock(ticker string) error
+	InsertStockPrice(price *StockPrice) error
+	GetLastStockPrice(ticker string) (*StockPrice, error)
+	GetStockPriceHistory(ticker string, from time.Time, to time.Time) ([]*StockPrice, error)
+	GetStockSession(sessionDate time.Time) (*StockSession, error)
+	InsertStockSession(session *StockSession, prices []*StockPrice) error
 }
 
 type client struct {
@@ -69,11 +103,17 @@ type (
 		UpdatedAt          time.Time       `db:"updated_at" name:"updated_at"`
 	}
 
+	// TS is the start of the hour bucket, SourceTS is the exchange time of the price.
+	// DerivedFrom is the quote asset a cross rate was computed from, nil for fetched pairs
 	Price struct {
-		Fromsymbol string          `db:"fromsym" name:"fromsym"`
-		Tosymbol   string          `db:"tosym" name:"tosym"`
-		Last_price decimal.Decimal `db:"last_price" name:"last_price"`
-		TS         time.Time       `db:"ts" name:"ts"`
+		Fromsymbol  string          `db:"fromsym" name:"fromsym"`
+		Tosymbol    string          `db:"tosym" name:"tosym"`
+		Last_price  decimal.Decimal `db:"last_price" name:"last_price"`
+		TS          time.Time       `db:"ts" name:"ts"`
+		SourceTS    time.Time       `db:"source_ts" name:"source_ts"`
+		DerivedFrom *string         `db:"derived_from" name:"derived_from"`
+		// Sources are the providers the price was computed from
+		Sources pq.StringArray `db:"sources" name:"sources"`
 	}
 
 	CoinInfo struct {
@@ -85,9 +125,13 @@ type (
 
 func (c *client) InsertPrice(price *Price) error {
 	query := `
-		INSERT INTO one_hour_price (fromsym, tosym, last_price, ts)
-		VALUES (:fromsym, :tosym, :last_price, :ts)
-		ON CONFLICT DO NOTHING;
+		INSERT INTO one_hour_price (fromsym, tosym, last_price, ts, source_ts, derived_from, sources)
+		VALUES (:fromsym, :tosym, :last_price, :ts, :source_ts, :derived_from, :sources)
+		ON CONFLICT (fromsym, tosym, ts) DO UPDATE SET
+			last_price = EXCLUDED.last_price,
+			source_ts = EXCLUDED.source_ts,
+			derived_from = EXCLUDED.derived_from,
+			sources = EXCLUDED.sources;
 	`
 	return c.SafeTx(func(tx *sqlx.Tx) error {
 		_, err := tx.NamedExec(query, price)
@@ -98,22 +142,38 @@ func (c *client) InsertPrice(price *Price) error {
 	})
 }
 
-func (c *client) GetLastHourPriceBySymbol(symbol string) (price *Price, err error) {
+func (c *client) GetLastHourPriceBySymbol(symbol string, quote string) (price *Price, err error) {
 	var prices []*Price
-	query := `SELECT fromsym, tosym, last_price, ts FROM one_hour_price WHERE fromsym = $1 ORDER BY ts DESC LIMIT 1`
-	err = c.Select(&prices, query, symbol)
+	query := `
+		SELECT fromsym, tosym, last_price, ts, source_ts, derived_from, sources
+		FROM one_hour_price
+		WHERE fromsym = $1 AND tosym = $2
+		ORDER BY ts DESC LIMIT 1
+	`
+	err = c.Select(&prices, query, symbol, quote)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get last hour price for symbol %s: %w", symbol, err)
+		return nil, fmt.Errorf("failed to get last hour price for symbol %s/%s: %w", symbol, quote, err)
 	}
 	if len(prices) == 0 {
-		return nil, fmt.Errorf("no price found for symbol %s", symbol)
+		return nil, fmt.Errorf("no price found for symbol %s/%s", symbol, quote)
 	}
 	return prices[0], nil
 }
 
+// returns nil if there are no prices yet
+func (c *client) GetLatestPriceTime() (*time.Time, error) {
+	var latest *time.Time
+	query := `SELECT MAX(ts) FROM one_hour_price`
+	err := c.Get(&latest, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest price time: %w", err)
+	}
+	return latest, nil
+}
+
 func (c *client) GetTickers() ([]string, error) {
 	var tickers []string
-	query := `SELECT DISTINCT ticker FROM coin ORDER BY ticker`
+	query := `SELECT DISTINCT ticker FROM coin WHERE is_active AND is_tradeable AND is_visible ORDER BY ticker`
 	err := c.Select(&tickers, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tickers: %w", err)
@@ -176,7 +236,7 @@ func (c *client) GetChatCoinInfo(chatID string) ([]*CoinInfo, error) {
 
 // returns empty string if not found
 func (c *client) GetTicker(inputTicker string) (foundTicker string, err error) {
-	query := `SELECT ticker FROM coin where ticker = $1`
+	query := `SELECT ticker FROM coin where ticker = $1 AND is_active AND is_tradeable AND is_visible`
 	err = c.Get(&foundTicker, query, inputTicker)
 	if err != nil {
 		return "", fmt.Errorf("failed to get ticker %s: %w", inputTicker, err)
@@ -0,0 +1,169 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+type StockPrice struct {
+	Ticker      string          `db:"ticker" name:"ticker"`
+	SessionDate time.Time       `db:"session_date" name:"session_date"`
+	Open        decimal.Decimal `db:"open" name:"open"`
+	High        decimal.Decimal `db:"high" name:"high"`
+	Low         decimal.Decimal `db:"low" name:"low"`
+	Close       decimal.Decimal `db:"close" name:"close"`
+	Volume      decimal.Decimal `db:"volume" name:"volume"`
+	UpdatedAt   time.Time       `db:"updated_at" name:"updated_at"`
+}
+
+// StockSession records the tickers whose bars of the session were fetched in one grouped
+// request, Bars is the number of bars stored
+type StockSession struct {
+	SessionDate time.Time      `db:"session_date" name:"session_date"`
+	Tickers     pq.StringArray `db:"tickers" name:"tickers"`
+	Bars        int            `db:"bars" name:"bars"`
+	FetchedAt   time.Time      `db:"fetched_at" name:"fetched_at"`
+}
+
+func (c *client) GetStockTickers() ([]string, error) {
+	var tickers []string
+	query := `SELECT ticker FROM stock WHERE is_active ORDER BY ticker`
+	err := c.Select(&tickers, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock tickers: %w", err)
+	}
+	return tickers, nil
+}
+
+func (c *client) AddStock(ticker string) error {
+	query := `
+		INSERT INTO stock (ticker, is_active, created_at)
+		VALUES ($1, TRUE, NOW())
+		ON CONFLICT (ticker) DO UPDATE SET
+			is_active = TRUE;
+	`
+	return c.SafeTx(func(tx *sqlx.Tx) error {
+		_, err := tx.Exec(query, ticker)
+		if err != nil {
+			return fmt.Errorf("failed to add stock %s: %w", ticker, err)
+		}
+		return nil
+	})
+}
+
+// RemoveStock deactivates the ticker, stored prices are kept
+func (c *client) RemoveStock(ticker string) error {
+	query := `UPDATE stock SET is_active = FALSE WHERE ticker = $1`
+	return c.SafeTx(func(tx *sqlx.Tx) error {
+		_, err := tx.Exec(query, ticker)
+		if err != nil {
+			return fmt.Errorf("failed to remove stock %s: %w", ticker, err)
+		}
+		return nil
+	})
+}
+
+const insertStockPriceQuery = `
+	INSERT INTO stock_daily_price (ticker, session_date, open, high, low, close, volume, updated_at)
+	VALUES (:ticker, :session_date, :open, :high, :low, :close, :volume, :updated_at)
+	ON CONFLICT (ticker, session_date) DO UPDATE SET
+		open = EXCLUDED.open,
+		high = EXCLUDED.high,
+		low = EXCLUDED.low,
+		close = EXCLUDED.close,
+		volume = EXCLUDED.volume,
+		updated_at = EXCLUDED.updated_at;
+`
+
+func (c *client) InsertStockPrice(price *StockPrice) error {
+	return c.SafeTx(func(tx *sqlx.Tx) error {
+		_, err := tx.NamedExec(insertStockPriceQuery, price)
+		if err != nil {
+			return fmt.Errorf("failed to insert stock price for %s: %w", price.Ticker, err)
+		}
+		return nil
+	})
+}
+
+// returns nil if there is no stored price for the ticker or it is not on the watchlist anymore
+func (c *client) GetLastStockPrice(ticker string) (*StockPrice, error) {
+	var prices []*StockPrice
+	query := `
+		SELECT p.ticker, p.session_date, p.open, p.high, p.low, p.close, p.volume, p.updated_at
+		FROM stock_daily_price p
+		JOIN stock s ON s.ticker = p.ticker AND s.is_active
+		WHERE p.ticker = $1
+		ORDER BY p.session_date DESC
+		LIMIT 1
+	`
+	err := c.Select(&prices, query, ticker)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last stock price for %s: %w", ticker, err)
+	}
+	if len(prices) == 0 {
+		return nil, nil
+	}
+	return prices[0], nil
+}
+
+func (c *client) GetStockPriceHistory(ticker string, from time.Time, to time.Time) ([]*StockPrice, error) {
+	var prices []*StockPrice
+	query := `
+		SELECT ticker, session_date, open, high, low, close, volume, updated_at
+		FROM stock_daily_price
+		WHERE ticker = $1 AND session_date >= $2 AND session_date < $3
+		ORDER BY session_date
+	`
+	err := c.Select(&prices, query, ticker, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock price history for %s: %w", ticker, err)
+	}
+	return prices, nil
+}
+
+// returns nil if no grouped request was made for the session
+func (c *client) GetStockSession(sessionDate time.Time) (*StockSession, error) {
+	var sessions []*StockSession
+	query := `
+		SELECT session_date, tickers, bars, fetched_at
+		FROM stock_session
+		WHERE session_date = $1
+	`
+	err := c.Select(&sessions, query, sessionDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock session %s: %w", sessionDate.Format(time.DateOnly), err)
+	}
+	if len(sessions) == 0 {
+		return nil, nil
+	}
+	return sessions[0], nil
+}
+
+// InsertStockSession stores the bars of the session together with the record of the request,
+// tickers of an earlier request of the same session are kept
+func (c *client) InsertStockSession(session *StockSession, prices []*StockPrice) error {
+	query := `
+		INSERT INTO stock_session (session_date, tickers, bars, fetched_at)
+		VALUES (:session_date, :tickers, :bars, :fetched_at)
+		ON CONFLICT (session_date) DO UPDATE SET
+			tickers = ARRAY(SELECT DISTINCT unnest(stock_session.tickers || EXCLUDED.tickers) ORDER BY 1),
+			bars = stock_session.bars + EXCLUDED.bars,
+			fetched_at = EXCLUDED.fetched_at;
+	`
+	return c.SafeTx(func(tx *sqlx.Tx) error {
+		for _, price := range prices {
+			if _, err := tx.NamedExec(insertStockPriceQuery, price); err != nil {
+				return fmt.Errorf("failed to insert stock price for %s: %w", price.Ticker, err)
+			}
+		}
+		_, err := tx.NamedExec(query, session)
+		if err != nil {
+			return fmt.Errorf("failed to insert stock session %s: %w", session.SessionDate.Format(time.DateOnly), err)
+		}
+		return nil
+	})
+}
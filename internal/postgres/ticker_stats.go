@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+// TickerStats is the 24 hour window of a pair as of the hour bucket TS
+type TickerStats struct {
+	Fromsymbol         string          `db:"fromsym" name:"fromsym"`
+	Tosymbol           string          `db:"tosym" name:"tosym"`
+	TS                 time.Time       `db:"ts" name:"ts"`
+	PriceChange        decimal.Decimal `db:"price_change" name:"price_change"`
+	PriceChangePercent decimal.Decimal `db:"price_change_percent" name:"price_change_percent"`
+	High               decimal.Decimal `db:"high" name:"high"`
+	Low                decimal.Decimal `db:"low" name:"low"`
+	Volume             decimal.Decimal `db:"volume" name:"volume"`
+	QuoteVolume        decimal.Decimal `db:"quote_volume" name:"quote_volume"`
+	OpenTime           time.Time       `db:"open_time" name:"open_time"`
+	CloseTime          time.Time       `db:"close_time" name:"close_time"`
+}
+
+func (c *client) InsertTickerStats(stats *TickerStats) error {
+	query := `
+		INSERT INTO ticker_24h_stats (fromsym, tosym, ts, price_change, price_change_percent,
+			high, low, volume, quote_volume, open_time, close_time)
+		VALUES (:fromsym, :tosym, :ts, :price_change, :price_change_percent,
+			:high, :low, :volume, :quote_volume, :open_time, :close_time)
+		ON CONFLICT (fromsym, tosym, ts) DO UPDATE SET
+			price_change = EXCLUDED.price_change,
+			price_change_percent = EXCLUDED.price_change_percent,
+			high = EXCLUDED.high,
+			low = EXCLUDED.low,
+			volume = EXCLUDED.volume,
+			quote_volume = EXCLUDED.quote_volume,
+			open_time = EXCLUDED.open_time,
+			close_time = EXCLUDED.close_time;
+	`
+	return c.SafeTx(func(tx *sqlx.Tx) error {
+		_, err := tx.NamedExec(query, stats)
+		if err != nil {
+			return fmt.Errorf("failed to insert 24h stats for %s->%s: %w", stats.Fromsymbol, stats.Tosymbol, err)
+		}
+		return nil
+	})
+}
+
+// GetLastTickerStats returns the newest stats of the coin against its quote asset, nil if there are none
+func (c *client) GetLastTickerStats(symbol string) (*TickerStats, error) {
+	var stats []*TickerStats
+	query := `
+		SELECT fromsym, tosym, ts, price_change, price_change_percent,
+			high, low, volume, quote_volume, open_time, close_time
+		FROM ticker_24h_stats
+		WHERE fromsym = $1
+		ORDER BY ts DESC LIMIT 1
+	`
+	err := c.Select(&stats, query, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get 24h stats for symbol %s: %w", symbol, err)
+	}
+	if len(stats) == 0 {
+		return nil, nil
+	}
+	return stats[0], nil
+}
+
+// DeleteTickerStatsBefore removes stats older than cutoff, they are not rolled up
+func (c *client) DeleteTickerStatsBefore(cutoff time.Time) (int64, error) {
+	var deleted int64
+	query := `DELETE FROM ticker_24h_stats WHERE ts < $1`
+	err := c.SafeTx(func(tx *sqlx.Tx) error {
+		result, err := tx.Exec(query, cutoff)
+		if err != nil {
+			return fmt.Errorf("failed to delete 24h stats before %s: %w", cutoff, err)
+		}
+		deleted, err = result.RowsAffected()
+		return err
+	})
+	return deleted, err
+}
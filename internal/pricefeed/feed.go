@@ -0,0 +1,78 @@
+package pricefeed
+
+import (
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// Update is a live price of a coin
+type Update struct {
+	Symbol string
+	Quote  string
+	Price  decimal.Decimal
+	Time   time.Time
+	Source string
+}
+
+// Feed fans out live prices to in-process subscribers and keeps the last price of every symbol
+type Feed struct {
+	mu     sync.RWMutex
+	last   map[string]Update
+	subs   map[int]chan Update
+	nextID int
+	log    *zap.Logger
+}
+
+func New(logger *zap.Logger) *Feed {
+	return &Feed{
+		last: make(map[string]Update),
+		subs: make(map[int]chan Update),
+		log:  logger,
+	}
+}
+
+// Publish never blocks, updates are dropped for subscribers that don't keep up
+func (f *Feed) Publish(update Update) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.last[update.Symbol] = update
+	for id, ch := range f.subs {
+		select {
+		case ch <- update:
+		default:
+			f.log.Debug("Price update dropped for slow subscriber", zap.Int("subscriber", id), zap.String("symbol", update.Symbol))
+		}
+	}
+}
+
+// Subscribe returns a channel of updates and a function to stop the subscription
+func (f *Feed) Subscribe(buffer int) (<-chan Update, func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := f.nextID
+	f.nextID++
+	ch := make(chan Update, buffer)
+	f.subs[id] = ch
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			delete(f.subs, id)
+			close(ch)
+		})
+	}
+}
+
+func (f *Feed) Last(symbol string) (Update, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	update, ok := f.last[symbol]
+	return update, ok
+}
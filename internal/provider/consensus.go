@@ -0,0 +1,208 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/config"
+	"go.uber.org/zap"
+)
+
+const (
+	MethodMedian         = "median"
+	MethodVolumeWeighted = "volume_weighted"
+	defaultMaxDeviation  = 0.02
+)
+
+// consensus agrees on a price from quotes of several providers
+type consensus struct {
+	enabled      bool
+	method       string
+	maxDeviation decimal.Decimal
+}
+
+func newConsensus(conf config.Consensus) (consensus, error) {
+	c := consensus{enabled: conf.Enabled, method: conf.Method}
+	switch c.method {
+	case "":
+		c.method = MethodMedian
+	case MethodMedian, MethodVolumeWeighted:
+	default:
+		return c, fmt.Errorf("unknown consensus method %s", conf.Method)
+	}
+	if conf.MaxDeviation < 0 {
+		return c, fmt.Errorf("consensus max deviation %f is negative", conf.MaxDeviation)
+	}
+	maxDeviation := conf.MaxDeviation
+	if maxDeviation == 0 {
+		maxDeviation = defaultMaxDeviation
+	}
+	c.maxDeviation = decimal.NewFromFloat(maxDeviation)
+	return c, nil
+}
+
+// agree computes the consensus of quotes ordered by provider preference.
+// Quotes deviating from the median beyond the limit are rejected.
+func (c consensus) agree(candidates []Quote) Quote {
+	median := medianPrice(candidates)
+	var accepted []Quote
+	var rejected []string
+	for _, quote := range candidates {
+		if median.IsPositive() && quote.Price.Sub(median).Abs().Div(median).GreaterThan(c.maxDeviation) {
+			rejected = append(rejected, quote.Source)
+			continue
+		}
+		accepted = append(accepted, quote)
+	}
+	// two quotes that disagree deviate equally from their mean, the preferred provider is trusted
+	if len(accepted) == 0 {
+		accepted = candidates[:1]
+		rejected = rejected[1:]
+	}
+
+	agreed := accepted[0]
+	agreed.Price = c.price(accepted)
+	agreed.Rejected = rejected
+	agreed.Sources = make([]string, 0, len(accepted))
+	for _, quote := range accepted {
+		agreed.Sources = append(agreed.Sources, quote.Source)
+		if quote.Time.After(agreed.Time) {
+			agreed.Time = quote.Time
+		}
+	}
+	return agreed
+}
+
+// price weights accepted quotes by volume if every provider reported it, the median is used otherwise
+func (c consensus) price(accepted []Quote) decimal.Decimal {
+	if c.method != MethodVolumeWeighted {
+		return medianPrice(accepted)
+	}
+	var weighted, volume decimal.Decimal
+	for _, quote := range accepted {
+		if !quote.Volume.IsPositive() {
+			return medianPrice(accepted)
+		}
+		weighted = weighted.Add(quote.Price.Mul(quote.Volume))
+		volume = volume.Add(quote.Volume)
+	}
+	return weighted.Div(volume)
+}
+
+func medianPrice(quotes []Quote) decimal.Decimal {
+	prices := make([]decimal.Decimal, 0, len(quotes))
+	for _, quote := range quotes {
+		prices = append(prices, quote.Price)
+	}
+	sort.Slice(prices, func(i, j int) bool {
+		return prices[i].LessThan(prices[j])
+	})
+	middle := len(prices) / 2
+	if len(prices)%2 == 1 {
+		return prices[middle]
+	}
+	return prices[middle-1].Add(prices[middle]).Div(decimal.NewFromInt(2))
+}
+
+// ConsensusEnabled reports whether hourly prices are agreed between providers
+func (r *Registry) ConsensusEnabled() bool {
+	return r.consensus.enabled
+}
+
+// BatchConsensusPrice asks every provider of the assets at once and agrees on a price per asset.
+// A provider that fails doesn't contribute, so an asset is priced as long as one of its providers answers.
+// Assets no provider lists are returned as unknown, an error is returned if some assets were left
+// without a quote because of provider failures.
+func (r *Registry) BatchConsensusPrice(assets []Asset) ([]Quote, []Asset, error) {
+	var errs []error
+	var requested []Asset
+	exhausted := false
+	groups := make(map[PriceProvider][]Asset)
+	var order []PriceProvider
+	for _, asset := range assets {
+		providers := r.For(asset)
+		if len(providers) == 0 {
+			errs = append(errs, fmt.Errorf("%s: %w", asset, ErrNoProvider))
+			exhausted = true
+			continue
+		}
+		requested = append(requested, asset)
+		for _, p := range providers {
+			if _, ok := groups[p]; !ok {
+				order = append(order, p)
+			}
+			groups[p] = append(groups[p], asset)
+		}
+	}
+
+	type result struct {
+		quotes []Quote
+		err    error
+	}
+	results := make([]result, len(order))
+	var wg sync.WaitGroup
+	for i, p := range order {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i].err = r.call(p, len(groups[p]), func() (err error) {
+				results[i].quotes, _, err = p.BatchLastPrice(groups[p])
+				return err
+			})
+		}()
+	}
+	wg.Wait()
+
+	collected := make(map[Asset]map[string]Quote)
+	failed := make(map[Asset]bool)
+	for i, p := range order {
+		if err := results[i].err; err != nil {
+			if !errors.Is(err, ErrCircuitOpen) {
+				r.log.Warn("Provider failed to return batch of prices", zap.String("provider", p.Name()), zap.Int("assets", len(groups[p])), zap.Error(err))
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+			for _, asset := range groups[p] {
+				failed[asset] = true
+			}
+			continue
+		}
+		r.breakers[p.Name()].quoted(results[i].quotes...)
+		for _, quote := range results[i].quotes {
+			if collected[quote.Asset] == nil {
+				collected[quote.Asset] = make(map[string]Quote)
+			}
+			collected[quote.Asset][p.Name()] = quote
+		}
+	}
+
+	var quotes []Quote
+	var unknown []Asset
+	for _, asset := range requested {
+		var candidates []Quote
+		for _, p := range r.For(asset) {
+			if quote, ok := collected[asset][p.Name()]; ok {
+				candidates = append(candidates, quote)
+			}
+		}
+		switch {
+		case len(candidates) != 0:
+			quote := r.consensus.agree(candidates)
+			if len(quote.Rejected) != 0 {
+				r.log.Warn("Outlier quotes rejected", zap.Stringer("asset", asset), zap.Strings("rejected", quote.Rejected), zap.Strings("sources", quote.Sources))
+			}
+			quotes = append(quotes, quote)
+		case failed[asset]:
+			exhausted = true
+		default:
+			unknown = append(unknown, asset)
+		}
+	}
+
+	if exhausted {
+		return quotes, unknown, errors.Join(errs...)
+	}
+	return quotes, unknown, nil
+}
@@ -0,0 +1,8 @@
+package provider
+
+import "time"
+
+// SetNow replaces the clock of the registry
+func SetNow(r *Registry, now func() time.Time) {
+	r.now = now
+}
@@ -0,0 +1,277 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zheka156/market_data/internal/config"
+	"go.uber.org/zap"
+)
+
+// ErrCircuitOpen is returned instead of calling a provider that failed too often recently
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type CircuitState string
+
+const (
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen short-circuits calls of the provider
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets a single probe call through, it closes the circuit on success
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+const (
+	defaultBreakerWindow       = 20
+	defaultBreakerMinCalls     = 5
+	defaultBreakerMaxErrorRate = 0.5
+	defaultBreakerSlowCall     = 10 * time.Second
+	defaultBreakerOpenFor      = time.Minute
+)
+
+func newBreakerConfig(conf config.CircuitBreaker) (config.CircuitBreaker, error) {
+	if conf.Window <= 0 {
+		conf.Window = defaultBreakerWindow
+	}
+	if conf.MinCalls <= 0 {
+		conf.MinCalls = defaultBreakerMinCalls
+	}
+	if conf.MinCalls > conf.Window {
+		return conf, fmt.Errorf("min calls %d exceed the window of %d calls", conf.MinCalls, conf.Window)
+	}
+	if conf.MaxErrorRate == 0 {
+		conf.MaxErrorRate = defaultBreakerMaxErrorRate
+	}
+	if conf.MaxErrorRate < 0 || conf.MaxErrorRate > 1 {
+		return conf, fmt.Errorf("max error rate %v is not between 0 and 1", conf.MaxErrorRate)
+	}
+	if conf.SlowCall <= 0 {
+		conf.SlowCall = defaultBreakerSlowCall
+	}
+	if conf.OpenFor <= 0 {
+		conf.OpenFor = defaultBreakerOpenFor
+	}
+	return conf, nil
+}
+
+// ProviderHealth is the state of a provider over its recent calls.
+// Score is the share of successful calls in the window, 1 if there were none.
+type ProviderHealth struct {
+	Name       string
+	State      CircuitState
+	Score      float64
+	Calls      int
+	Failures   int
+	AvgLatency time.Duration
+	// LastQuote is the newest source time of a returned price, zero if the provider hasn't quoted yet
+	LastQuote   time.Time
+	LastSuccess time.Time
+	LastFailure time.Time
+	LastError   string
+	OpenedAt    time.Time
+}
+
+type outcome struct {
+	failed  bool
+	latency time.Duration
+}
+
+// breaker tracks the recent calls of a provider and decides whether it may be called
+type breaker struct {
+	conf config.CircuitBreaker
+
+	mu          sync.Mutex
+	outcomes    []outcome
+	next        int
+	state       CircuitState
+	openedAt    time.Time
+	probing     bool
+	lastQuote   time.Time
+	lastSuccess time.Time
+	lastFailure time.Time
+	lastError   string
+}
+
+func newBreaker(conf config.CircuitBreaker) *breaker {
+	return &breaker{conf: conf, state: CircuitClosed}
+}
+
+// allow reports whether the provider may be called, an open circuit turns half open after OpenFor
+// and lets one probe through at a time
+func (b *breaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case CircuitOpen:
+		if now.Sub(b.openedAt) < b.conf.OpenFor {
+			return false
+		}
+		b.state = CircuitHalfOpen
+		b.probing = true
+		return true
+	case CircuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+// record adds the outcome of a call for units assets and returns the state it moved the circuit to,
+// if any. A call is slow if it took longer than SlowCall per asset, providers pace batches by asset.
+// A probe is judged by its answer only, a slow one still closes the circuit.
+func (b *breaker) record(now time.Time, latency time.Duration, units int, err error) (CircuitState, bool) {
+	slow := latency > b.conf.SlowCall*time.Duration(max(units, 1))
+	failed := isFailure(err) || slow && b.state != CircuitHalfOpen
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if failed {
+		b.lastFailure = now
+		if err != nil {
+			b.lastError = err.Error()
+		} else {
+			b.lastError = fmt.Sprintf("slow call of %s", latency.Round(time.Millisecond))
+		}
+	} else {
+		b.lastSuccess = now
+	}
+	if len(b.outcomes) < b.conf.Window {
+		b.outcomes = append(b.outcomes, outcome{failed: failed, latency: latency})
+	} else {
+		b.outcomes[b.next] = outcome{failed: failed, latency: latency}
+		b.next = (b.next + 1) % b.conf.Window
+	}
+
+	switch b.state {
+	case CircuitHalfOpen:
+		b.probing = false
+		if failed {
+			b.state, b.openedAt = CircuitOpen, now
+			return b.state, true
+		}
+		b.state = CircuitClosed
+		b.outcomes, b.next = nil, 0
+		return b.state, true
+	case CircuitClosed:
+		calls, failures := b.counts()
+		if calls >= b.conf.MinCalls && float64(failures) >= b.conf.MaxErrorRate*float64(calls) {
+			b.state, b.openedAt = CircuitOpen, now
+			return b.state, true
+		}
+	}
+	return b.state, false
+}
+
+// quoted keeps the newest source time of the prices the provider returned
+func (b *breaker) quoted(quotes ...Quote) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, quote := range quotes {
+		if quote.Time.After(b.lastQuote) {
+			b.lastQuote = quote.Time
+		}
+	}
+}
+
+func (b *breaker) counts() (int, int) {
+	failures := 0
+	for _, o := range b.outcomes {
+		if o.failed {
+			failures++
+		}
+	}
+	return len(b.outcomes), failures
+}
+
+func (b *breaker) health(name string) ProviderHealth {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	calls, failures := b.counts()
+	h := ProviderHealth{
+		Name:        name,
+		State:       b.state,
+		Score:       1,
+		Calls:       calls,
+		Failures:    failures,
+		LastQuote:   b.lastQuote,
+		LastSuccess: b.lastSuccess,
+		LastFailure: b.lastFailure,
+		LastError:   b.lastError,
+	}
+	if b.state != CircuitClosed {
+		h.OpenedAt = b.openedAt
+	}
+	if calls != 0 {
+		var total time.Duration
+		for _, o := range b.outcomes {
+			total += o.latency
+		}
+		h.Score = float64(calls-failures) / float64(calls)
+		h.AvgLatency = total / time.Duration(calls)
+	}
+	return h
+}
+
+// isFailure tells provider failures from answers, an unknown asset or unsupported operation is an answer
+func isFailure(err error) bool {
+	return err != nil && !errors.Is(err, ErrUnknownAsset) && !errors.Is(err, ErrUnsupported)
+}
+
+// Tracker runs a call of units assets against a provider through its circuit breaker
+type Tracker func(units int, fn func() error) error
+
+// call runs fn against the provider unless its circuit is open and records the outcome
+func (r *Registry) call(p PriceProvider, units int, fn func() error) error {
+	return r.Track(p.Name(), units, fn)
+}
+
+// Track runs fn for units assets against the named provider unless its circuit is open and
+// records the outcome. It covers provider specific calls made outside the registry, fn is run
+// untracked for a provider that is not registered.
+func (r *Registry) Track(name string, units int, fn func() error) error {
+	b, ok := r.breakers[name]
+	if !ok {
+		return fn()
+	}
+	if !b.allow(r.now()) {
+		return ErrCircuitOpen
+	}
+	start := r.now()
+	err := fn()
+	end := r.now()
+	state, changed := b.record(end, end.Sub(start), units, err)
+	if changed {
+		switch state {
+		case CircuitOpen:
+			r.log.Warn("Provider circuit opened", zap.String("provider", name), zap.Error(err))
+		case CircuitClosed:
+			r.log.Info("Provider circuit closed", zap.String("provider", name))
+		}
+	}
+	return err
+}
+
+// Tracker returns Track bound to the named provider
+func (r *Registry) Tracker(name string) Tracker {
+	return func(units int, fn func() error) error {
+		return r.Track(name, units, fn)
+	}
+}
+
+// Health returns the health of registered providers in alphabetical order
+func (r *Registry) Health() []ProviderHealth {
+	health := make([]ProviderHealth, 0, len(r.breakers))
+	for name, b := range r.breakers {
+		health = append(health, b.health(name))
+	}
+	sort.Slice(health, func(i, j int) bool {
+		return health[i].Name < health[j].Name
+	})
+	return health
+}
@@ -0,0 +1,102 @@
+// Package provider abstracts price sources so prices are requested by asset
+// and the source is picked from configuration
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type AssetClass string
+
+const (
+	Crypto AssetClass = "crypto"
+	Stock  AssetClass = "stock"
+)
+
+var (
+	// ErrUnknownAsset is returned when the provider doesn't list the asset
+	ErrUnknownAsset = errors.New("unknown asset")
+	// ErrUnsupported is returned for operations the provider can't serve
+	ErrUnsupported = errors.New("not supported by provider")
+	ErrNoProvider  = errors.New("no provider configured")
+)
+
+// Asset is a priced instrument, e.g. BTC in USDT or AAPL in USD.
+// Source pins the provider of the asset, empty means the providers configured for the symbol or class.
+type Asset struct {
+	Symbol string     `json:"symbol"`
+	Quote  string     `json:"quote"`
+	Class  AssetClass `json:"class"`
+	Source string     `json:"source,omitempty"`
+}
+
+func (a Asset) String() string {
+	return a.Symbol + "/" + a.Quote
+}
+
+// Quote is the last price of an asset, Time is the source time of the price.
+// Volume is the 24 hour volume in the base asset, zero if the provider doesn't report it.
+type Quote struct {
+	Asset  Asset
+	Price  decimal.Decimal
+	Time   time.Time
+	Source string
+	Volume decimal.Decimal
+	// Sources are the providers a consensus price was computed from, Source is the preferred of them.
+	// Rejected are providers whose quote deviated too far from the others.
+	Sources  []string
+	Rejected []string
+}
+
+type Candle struct {
+	OpenTime time.Time
+	Open     decimal.Decimal
+	High     decimal.Decimal
+	Low      decimal.Decimal
+	Close    decimal.Decimal
+	Volume   decimal.Decimal
+	// Source is the provider the candle came from, the registry sets it
+	Source string
+}
+
+type PriceProvider interface {
+	Name() string
+	LastPrice(asset Asset) (*Quote, error)
+	// BatchLastPrice returns quotes of the assets, assets the provider doesn't list are returned separately
+	BatchLastPrice(assets []Asset) (quotes []Quote, unknown []Asset, err error)
+	// History returns candles with open times in [from, to]
+	History(asset Asset, from time.Time, to time.Time, interval time.Duration) ([]Candle, error)
+	SupportedSymbols() ([]Asset, error)
+	// Health returns an error if the provider can't serve requests
+	Health() error
+}
+
+var intervals = map[string]time.Duration{
+	"1m":  time.Minute,
+	"3m":  3 * time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"30m": 30 * time.Minute,
+	"1h":  time.Hour,
+	"2h":  2 * time.Hour,
+	"4h":  4 * time.Hour,
+	"6h":  6 * time.Hour,
+	"8h":  8 * time.Hour,
+	"12h": 12 * time.Hour,
+	"1d":  24 * time.Hour,
+	"3d":  3 * 24 * time.Hour,
+	"1w":  7 * 24 * time.Hour,
+}
+
+// ParseInterval converts the candle interval notation, e.g. 1h or 1d, to a duration
+func ParseInterval(interval string) (time.Duration, error) {
+	d, ok := intervals[interval]
+	if !ok {
+		return 0, fmt.Errorf("interval %s: %w", interval, ErrUnsupported)
+	}
+	return d, nil
+}
@@ -0,0 +1,23 @@
+// Package providertest has helpers shared by tests of price providers
+package providertest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zheka156/market_data/internal/provider"
+)
+
+// Crypto returns the crypto asset of the pair
+func Crypto(symbol string, quote string) provider.Asset {
+	return provider.Asset{Symbol: symbol, Quote: quote, Class: provider.Crypto}
+}
+
+// NewServer serves the handler until the test ends, its URL is the base URL of the provider client
+func NewServer(t *testing.T, handler http.Handler) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
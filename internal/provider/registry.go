@@ -0,0 +1,233 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/zheka156/market_data/internal/config"
+	"go.uber.org/zap"
+)
+
+// Registry picks providers of an asset by its symbol or class, the following providers are fallbacks
+type Registry struct {
+	log       *zap.Logger
+	providers map[string]PriceProvider
+	classes   map[AssetClass][]PriceProvider
+	symbols   map[string][]PriceProvider
+	consensus consensus
+	breakers  map[string]*breaker
+	now       func() time.Time
+}
+
+// NewRegistry fails if the configuration refers to a provider that is not registered
+func NewRegistry(logger *zap.Logger, conf config.Providers, providers ...PriceProvider) (*Registry, error) {
+	r := &Registry{
+		log:       logger,
+		providers: make(map[string]PriceProvider, len(providers)),
+		classes:   make(map[AssetClass][]PriceProvider, len(conf.Classes)),
+		symbols:   make(map[string][]PriceProvider, len(conf.Symbols)),
+		breakers:  make(map[string]*breaker, len(providers)),
+		now:       time.Now,
+	}
+	breakerConf, err := newBreakerConfig(conf.CircuitBreaker)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure circuit breaker: %w", err)
+	}
+	for _, p := range providers {
+		r.providers[p.Name()] = p
+		r.breakers[p.Name()] = newBreaker(breakerConf)
+	}
+
+	resolve := func(names []string) ([]PriceProvider, error) {
+		resolved := make([]PriceProvider, 0, len(names))
+		for _, name := range names {
+			p, ok := r.providers[name]
+			if !ok {
+				return nil, fmt.Errorf("provider %s is not registered", name)
+			}
+			resolved = append(resolved, p)
+		}
+		return resolved, nil
+	}
+	for class, names := range conf.Classes {
+		resolved, err := resolve(names)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure %s providers: %w", class, err)
+		}
+		r.classes[AssetClass(class)] = resolved
+	}
+	for symbol, names := range conf.Symbols {
+		resolved, err := resolve(names)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure %s providers: %w", symbol, err)
+		}
+		r.symbols[symbol] = resolved
+	}
+	consensus, err := newConsensus(conf.Consensus)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure consensus: %w", err)
+	}
+	r.consensus = consensus
+	return r, nil
+}
+
+// Get returns a registered provider by name
+func (r *Registry) Get(name string) (PriceProvider, bool) {
+	p, ok := r.providers[name]
+	return p, ok
+}
+
+// Names returns registered provider names in alphabetical order
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PinnedSymbols returns the preferred provider of every symbol with configured providers
+func (r *Registry) PinnedSymbols() map[string]string {
+	pinned := make(map[string]string, len(r.symbols))
+	for symbol, providers := range r.symbols {
+		if len(providers) != 0 {
+			pinned[symbol] = providers[0].Name()
+		}
+	}
+	return pinned
+}
+
+// For returns providers of the asset in order of preference, a pinned asset has its source only
+func (r *Registry) For(asset Asset) []PriceProvider {
+	if asset.Source != "" {
+		if p, ok := r.providers[asset.Source]; ok {
+			return []PriceProvider{p}
+		}
+		return nil
+	}
+	if providers, ok := r.symbols[asset.Symbol]; ok {
+		return providers
+	}
+	return r.classes[asset.Class]
+}
+
+// LastPrice asks providers of the asset in order until one answers, providers with an open circuit are skipped
+func (r *Registry) LastPrice(asset Asset) (*Quote, error) {
+	providers := r.For(asset)
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("%s: %w", asset, ErrNoProvider)
+	}
+	var errs []error
+	for _, p := range providers {
+		var quote *Quote
+		err := r.call(p, 1, func() (err error) {
+			quote, err = p.LastPrice(asset)
+			return err
+		})
+		if err == nil {
+			r.breakers[p.Name()].quoted(*quote)
+			return quote, nil
+		}
+		if !errors.Is(err, ErrCircuitOpen) {
+			r.log.Warn("Provider failed to return last price", zap.String("provider", p.Name()), zap.Stringer("asset", asset), zap.Error(err))
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+	}
+	return nil, errors.Join(errs...)
+}
+
+// BatchLastPrice groups assets by provider. Assets a provider failed for or doesn't list go to the next provider,
+// as do assets of a provider with an open circuit.
+// Assets every provider rejected are returned as unknown, an error is returned if some assets were left
+// without a quote because of provider failures.
+func (r *Registry) BatchLastPrice(assets []Asset) ([]Quote, []Asset, error) {
+	var quotes []Quote
+	var unknown, exhausted []Asset
+	var errs []error
+	// an asset is unknown only if no provider failed for it
+	failed := make(map[Asset]bool)
+
+	pending := assets
+	for attempt := 0; len(pending) != 0; attempt++ {
+		groups := make(map[PriceProvider][]Asset)
+		var order []PriceProvider
+		for _, asset := range pending {
+			providers := r.For(asset)
+			switch {
+			case len(providers) == 0:
+				errs = append(errs, fmt.Errorf("%s: %w", asset, ErrNoProvider))
+				exhausted = append(exhausted, asset)
+			case attempt < len(providers):
+				p := providers[attempt]
+				if _, ok := groups[p]; !ok {
+					order = append(order, p)
+				}
+				groups[p] = append(groups[p], asset)
+			case failed[asset]:
+				exhausted = append(exhausted, asset)
+			default:
+				unknown = append(unknown, asset)
+			}
+		}
+
+		pending = nil
+		for _, p := range order {
+			group := groups[p]
+			var fetched []Quote
+			var rejected []Asset
+			err := r.call(p, len(group), func() (err error) {
+				fetched, rejected, err = p.BatchLastPrice(group)
+				return err
+			})
+			if err != nil {
+				if !errors.Is(err, ErrCircuitOpen) {
+					r.log.Warn("Provider failed to return batch of prices", zap.String("provider", p.Name()), zap.Int("assets", len(group)), zap.Error(err))
+				}
+				errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+				for _, asset := range group {
+					failed[asset] = true
+				}
+				pending = append(pending, group...)
+				continue
+			}
+			r.breakers[p.Name()].quoted(fetched...)
+			quotes = append(quotes, fetched...)
+			pending = append(pending, rejected...)
+		}
+	}
+
+	if len(exhausted) != 0 {
+		return quotes, unknown, errors.Join(errs...)
+	}
+	return quotes, unknown, nil
+}
+
+// History asks providers of the asset in order, providers that don't serve history or have an open circuit are skipped
+func (r *Registry) History(asset Asset, from time.Time, to time.Time, interval time.Duration) ([]Candle, error) {
+	providers := r.For(asset)
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("%s: %w", asset, ErrNoProvider)
+	}
+	var errs []error
+	for _, p := range providers {
+		var candles []Candle
+		err := r.call(p, 1, func() (err error) {
+			candles, err = p.History(asset, from, to, interval)
+			return err
+		})
+		if err == nil {
+			for i := range candles {
+				candles[i].Source = p.Name()
+			}
+			return candles, nil
+		}
+		if !errors.Is(err, ErrUnsupported) && !errors.Is(err, ErrCircuitOpen) {
+			r.log.Warn("Provider failed to return history", zap.String("provider", p.Name()), zap.Stringer("asset", asset), zap.Error(err))
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+	}
+	return nil, errors.Join(errs...)
+}
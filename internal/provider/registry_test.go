@@ -0,0 +1,353 @@
+package provider_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/config"
+	"github.com/zheka156/market_data/internal/provider"
+	"github.com/zheka156/market_data/internal/provider/providertest"
+	"go.uber.org/zap"
+)
+
+var errDown = errors.New("provider is down")
+
+// fakeProvider quotes the listed symbols at price or 1 if it is not set, every call fails while err is set
+type fakeProvider struct {
+	name   string
+	listed map[string]bool
+	price  decimal.Decimal
+	err    error
+	calls  int
+}
+
+func (p *fakeProvider) quote(asset provider.Asset) provider.Quote {
+	price := p.price
+	if price.IsZero() {
+		price = decimal.NewFromInt(1)
+	}
+	return provider.Quote{Asset: asset, Price: price, Time: time.Now(), Source: p.name}
+}
+
+func (p *fakeProvider) Name() string {
+	return p.name
+}
+
+func (p *fakeProvider) LastPrice(asset provider.Asset) (*provider.Quote, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	if !p.listed[asset.Symbol] {
+		return nil, provider.ErrUnknownAsset
+	}
+	quote := p.quote(asset)
+	return &quote, nil
+}
+
+func (p *fakeProvider) BatchLastPrice(assets []provider.Asset) ([]provider.Quote, []provider.Asset, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, nil, p.err
+	}
+	var quotes []provider.Quote
+	var unknown []provider.Asset
+	for _, asset := range assets {
+		if !p.listed[asset.Symbol] {
+			unknown = append(unknown, asset)
+			continue
+		}
+		quotes = append(quotes, p.quote(asset))
+	}
+	return quotes, unknown, nil
+}
+
+func (p *fakeProvider) History(asset provider.Asset, from time.Time, to time.Time, interval time.Duration) ([]provider.Candle, error) {
+	return nil, provider.ErrUnsupported
+}
+
+func (p *fakeProvider) SupportedSymbols() ([]provider.Asset, error) {
+	return nil, provider.ErrUnsupported
+}
+
+func (p *fakeProvider) Health() error {
+	return p.err
+}
+
+func listed(symbols ...string) map[string]bool {
+	m := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		m[symbol] = true
+	}
+	return m
+}
+
+func newTestRegistry(t *testing.T, conf config.Providers, providers ...provider.PriceProvider) *provider.Registry {
+	t.Helper()
+	r, err := provider.NewRegistry(zap.NewNop(), conf, providers...)
+	if err != nil {
+		t.Fatalf("failed to create registry: %s", err)
+	}
+	return r
+}
+
+func TestNewRegistryUnknownProvider(t *testing.T) {
+	conf := config.Providers{Classes: map[string][]string{"crypto": {"primary", "missing"}}}
+	if _, err := provider.NewRegistry(zap.NewNop(), conf, &fakeProvider{name: "primary"}); err == nil {
+		t.Fatal("expected an error for a provider that is not registered")
+	}
+}
+
+func TestRegistrySymbolOverride(t *testing.T) {
+	primary := &fakeProvider{name: "primary", listed: listed("BTC", "EURC")}
+	special := &fakeProvider{name: "special", listed: listed("EURC")}
+	r := newTestRegistry(t, config.Providers{
+		Classes: map[string][]string{"crypto": {"primary"}},
+		Symbols: map[string][]string{"EURC": {"special"}},
+	}, primary, special)
+
+	quote, err := r.LastPrice(providertest.Crypto("EURC", "USDT"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if quote.Source != "special" {
+		t.Errorf("expected EURC from the symbol override, got %s", quote.Source)
+	}
+	if providers := r.For(providertest.Crypto("BTC", "USDT")); len(providers) != 1 || providers[0] != primary {
+		t.Errorf("expected BTC from the class providers, got %v", providers)
+	}
+}
+
+func TestRegistryBatchFallback(t *testing.T) {
+	primary := &fakeProvider{name: "primary", listed: listed("BTC")}
+	fallback := &fakeProvider{name: "fallback", listed: listed("BTC", "ETH")}
+	r := newTestRegistry(t, config.Providers{Classes: map[string][]string{"crypto": {"primary", "fallback"}}}, primary, fallback)
+
+	quotes, unknown, err := r.BatchLastPrice([]provider.Asset{providertest.Crypto("BTC", "USDT"), providertest.Crypto("ETH", "USDT"), providertest.Crypto("DEAD", "USDT")})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	sources := map[string]string{}
+	for _, quote := range quotes {
+		sources[quote.Asset.Symbol] = quote.Source
+	}
+	if sources["BTC"] != "primary" || sources["ETH"] != "fallback" || len(quotes) != 2 {
+		t.Errorf("unexpected quote sources %v", sources)
+	}
+	if len(unknown) != 1 || unknown[0].Symbol != "DEAD" {
+		t.Errorf("expected DEAD to be unknown, got %v", unknown)
+	}
+}
+
+func TestRegistryBatchFailureIsNotUnknown(t *testing.T) {
+	primary := &fakeProvider{name: "primary", err: errDown}
+	fallback := &fakeProvider{name: "fallback", listed: listed("BTC")}
+	r := newTestRegistry(t, config.Providers{Classes: map[string][]string{"crypto": {"primary", "fallback"}}}, primary, fallback)
+
+	quotes, unknown, err := r.BatchLastPrice([]provider.Asset{providertest.Crypto("BTC", "USDT"), providertest.Crypto("ETH", "USDT")})
+	if !errors.Is(err, errDown) {
+		t.Fatalf("expected the primary failure, got %v", err)
+	}
+	if len(quotes) != 1 || quotes[0].Source != "fallback" {
+		t.Errorf("expected BTC from the fallback, got %+v", quotes)
+	}
+	// the primary could not be asked about ETH, the coin must not be treated as delisted
+	if len(unknown) != 0 {
+		t.Errorf("expected no unknown assets, got %v", unknown)
+	}
+}
+
+func TestRegistryNoProvider(t *testing.T) {
+	r := newTestRegistry(t, config.Providers{})
+
+	if _, err := r.LastPrice(providertest.Crypto("BTC", "USDT")); !errors.Is(err, provider.ErrNoProvider) {
+		t.Errorf("expected no provider error, got %v", err)
+	}
+	if _, _, err := r.BatchLastPrice([]provider.Asset{providertest.Crypto("BTC", "USDT")}); !errors.Is(err, provider.ErrNoProvider) {
+		t.Errorf("expected no provider error, got %v", err)
+	}
+}
+
+func TestRegistryPinnedSource(t *testing.T) {
+	primary := &fakeProvider{name: "primary", listed: listed("PEPE")}
+	pinned := &fakeProvider{name: "pinned", listed: listed("PEPE")}
+	r := newTestRegistry(t, config.Providers{Classes: map[string][]string{"crypto": {"primary"}}}, primary, pinned)
+
+	asset := providertest.Crypto("PEPE", "USDT")
+	asset.Source = "pinned"
+	quote, err := r.LastPrice(asset)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if quote.Source != "pinned" || primary.calls != 0 {
+		t.Errorf("expected the pinned provider only, got %s and %d primary calls", quote.Source, primary.calls)
+	}
+
+	asset.Source = "missing"
+	if _, err := r.LastPrice(asset); !errors.Is(err, provider.ErrNoProvider) {
+		t.Errorf("expected no provider error for an unregistered source, got %v", err)
+	}
+}
+
+func TestRegistryConsensusRejectsOutlier(t *testing.T) {
+	primary := &fakeProvider{name: "primary", listed: listed("BTC", "ETH"), price: decimal.NewFromInt(100)}
+	second := &fakeProvider{name: "second", listed: listed("BTC"), price: decimal.NewFromInt(101)}
+	wick := &fakeProvider{name: "wick", listed: listed("BTC"), price: decimal.NewFromInt(150)}
+	down := &fakeProvider{name: "down", err: errDown}
+	r := newTestRegistry(t, config.Providers{
+		Classes:   map[string][]string{"crypto": {"down", "primary", "second", "wick"}},
+		Consensus: config.Consensus{Enabled: true, MaxDeviation: 0.05},
+	}, primary, second, wick, down)
+
+	// the failing provider doesn't contribute, its assets are still priced by the others
+	quotes, unknown, err := r.BatchConsensusPrice([]provider.Asset{providertest.Crypto("BTC", "USDT"), providertest.Crypto("ETH", "USDT")})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(quotes) != 2 {
+		t.Fatalf("expected BTC and ETH quotes, got %+v", quotes)
+	}
+	btc := quotes[0]
+	if !btc.Price.Equal(decimal.RequireFromString("100.5")) || btc.Source != "primary" {
+		t.Errorf("expected the median of primary and second, got %s from %s", btc.Price, btc.Source)
+	}
+	if len(btc.Sources) != 2 || len(btc.Rejected) != 1 || btc.Rejected[0] != "wick" {
+		t.Errorf("expected wick to be rejected, got sources %v rejected %v", btc.Sources, btc.Rejected)
+	}
+	if eth := quotes[1]; len(eth.Sources) != 1 || eth.Sources[0] != "primary" {
+		t.Errorf("expected ETH from the primary only, got %v", eth.Sources)
+	}
+	if len(unknown) != 0 {
+		t.Errorf("expected no unknown assets, got %v", unknown)
+	}
+}
+
+func TestRegistryConsensusDisagreementKeepsPreferred(t *testing.T) {
+	primary := &fakeProvider{name: "primary", listed: listed("BTC"), price: decimal.NewFromInt(100)}
+	wick := &fakeProvider{name: "wick", listed: listed("BTC"), price: decimal.NewFromInt(150)}
+	r := newTestRegistry(t, config.Providers{
+		Classes:   map[string][]string{"crypto": {"primary", "wick"}},
+		Consensus: config.Consensus{Enabled: true, Method: provider.MethodVolumeWeighted},
+	}, primary, wick)
+
+	quotes, _, err := r.BatchConsensusPrice([]provider.Asset{providertest.Crypto("BTC", "USDT")})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(quotes) != 1 || !quotes[0].Price.Equal(decimal.NewFromInt(100)) || quotes[0].Rejected[0] != "wick" {
+		t.Errorf("expected the primary price with wick rejected, got %+v", quotes)
+	}
+}
+
+func TestNewRegistryUnknownConsensusMethod(t *testing.T) {
+	conf := config.Providers{Consensus: config.Consensus{Enabled: true, Method: "mean"}}
+	if _, err := provider.NewRegistry(zap.NewNop(), conf); err == nil {
+		t.Fatal("expected an error for an unknown consensus method")
+	}
+}
+
+func TestRegistryCircuitBreaker(t *testing.T) {
+	primary := &fakeProvider{name: "primary", listed: listed("BTC"), err: errDown}
+	fallback := &fakeProvider{name: "fallback", listed: listed("BTC")}
+	r := newTestRegistry(t, config.Providers{
+		Classes:        map[string][]string{"crypto": {"primary", "fallback"}},
+		CircuitBreaker: config.CircuitBreaker{Window: 4, MinCalls: 2, MaxErrorRate: 0.5, OpenFor: time.Minute},
+	}, primary, fallback)
+	now := time.Now()
+	provider.SetNow(r, func() time.Time { return now })
+
+	for i := 0; i < 3; i++ {
+		quote, err := r.LastPrice(providertest.Crypto("BTC", "USDT"))
+		if err != nil || quote.Source != "fallback" {
+			t.Fatalf("expected BTC from the fallback, got %+v (%v)", quote, err)
+		}
+	}
+	// the circuit opened after two failures, the third call went to the fallback only
+	if primary.calls != 2 {
+		t.Errorf("expected the open circuit to short-circuit the primary, got %d calls", primary.calls)
+	}
+	if health := r.Health(); health[1].Name != "primary" || health[1].State != provider.CircuitOpen || health[1].Score != 0 {
+		t.Errorf("expected the primary circuit open, got %+v", health)
+	}
+
+	primary.err = nil
+	now = now.Add(time.Minute)
+	quote, err := r.LastPrice(providertest.Crypto("BTC", "USDT"))
+	if err != nil || quote.Source != "primary" {
+		t.Fatalf("expected the probe to reach the primary, got %+v (%v)", quote, err)
+	}
+	if health := r.Health(); health[1].State != provider.CircuitClosed || health[1].Calls != 0 || health[1].LastError != errDown.Error() {
+		t.Errorf("expected the primary circuit closed after the probe, got %+v", health[1])
+	}
+}
+
+func TestRegistryCircuitProbeFailureReopens(t *testing.T) {
+	primary := &fakeProvider{name: "primary", listed: listed("BTC"), err: errDown}
+	r := newTestRegistry(t, config.Providers{
+		Classes:        map[string][]string{"crypto": {"primary"}},
+		CircuitBreaker: config.CircuitBreaker{Window: 2, MinCalls: 1, OpenFor: time.Minute},
+	}, primary)
+	now := time.Now()
+	provider.SetNow(r, func() time.Time { return now })
+
+	if _, _, err := r.BatchLastPrice([]provider.Asset{providertest.Crypto("BTC", "USDT")}); !errors.Is(err, errDown) {
+		t.Fatalf("expected the primary failure, got %v", err)
+	}
+	if _, _, err := r.BatchLastPrice([]provider.Asset{providertest.Crypto("BTC", "USDT")}); !errors.Is(err, provider.ErrCircuitOpen) {
+		t.Fatalf("expected an open circuit, got %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := r.LastPrice(providertest.Crypto("BTC", "USDT")); !errors.Is(err, errDown) {
+		t.Fatalf("expected the probe to fail, got %v", err)
+	}
+	if _, err := r.LastPrice(providertest.Crypto("BTC", "USDT")); !errors.Is(err, provider.ErrCircuitOpen) || primary.calls != 2 {
+		t.Errorf("expected the circuit to reopen after the failed probe, got %v and %d calls", err, primary.calls)
+	}
+}
+
+func TestRegistryUnknownAssetIsNotFailure(t *testing.T) {
+	primary := &fakeProvider{name: "primary", listed: listed("BTC")}
+	r := newTestRegistry(t, config.Providers{
+		Classes:        map[string][]string{"crypto": {"primary"}},
+		CircuitBreaker: config.CircuitBreaker{Window: 2, MinCalls: 1},
+	}, primary)
+
+	for i := 0; i < 3; i++ {
+		if _, err := r.LastPrice(providertest.Crypto("DEAD", "USDT")); !errors.Is(err, provider.ErrUnknownAsset) {
+			t.Fatalf("expected unknown asset, got %v", err)
+		}
+	}
+	if health := r.Health(); health[0].State != provider.CircuitClosed || health[0].Failures != 0 {
+		t.Errorf("expected unknown assets not to count as failures, got %+v", health[0])
+	}
+}
+
+func TestRegistrySlowCallScalesWithBatch(t *testing.T) {
+	primary := &fakeProvider{name: "primary", listed: listed("BTC")}
+	r := newTestRegistry(t, config.Providers{
+		Classes:        map[string][]string{"crypto": {"primary"}},
+		CircuitBreaker: config.CircuitBreaker{Window: 2, MinCalls: 1, SlowCall: time.Second},
+	}, primary)
+	now := time.Now()
+	provider.SetNow(r, func() time.Time { return now })
+	slow := func() error {
+		now = now.Add(5 * time.Second)
+		return nil
+	}
+
+	if err := r.Track("primary", 10, slow); err != nil {
+		t.Fatalf("expected the paced batch to pass, got %v", err)
+	}
+	if health := r.Health(); health[0].State != provider.CircuitClosed || health[0].Failures != 0 {
+		t.Errorf("expected a batch within its per asset budget not to fail, got %+v", health[0])
+	}
+	if err := r.Track("primary", 1, slow); err != nil {
+		t.Fatalf("expected the slow call to pass through, got %v", err)
+	}
+	if health := r.Health(); health[0].State != provider.CircuitOpen {
+		t.Errorf("expected the slow single call to open the circuit, got %+v", health[0])
+	}
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/zheka156/market_data/internal/provider"
+	"github.com/zheka156/market_data/internal/utils"
+)
+
+func (s *Server) GetQuarantinedCoins(c *fiber.Ctx) error {
+	coins, err := s.rep.GetQuarantinedCoins()
+	if err != nil {
+		s.log.Sugar().Errorf("Failed to get quarantined coins: %s", err)
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	response := make([]QuarantinedCoinResponse, 0, len(coins))
+	for _, coin := range coins {
+		response = append(response, QuarantinedCoinResponse{
+			Ticker:        coin.Ticker,
+			QuarantinedAt: coin.QuarantinedAt,
+			Reason:        coin.QuarantineReason,
+		})
+	}
+	return c.JSON(response)
+}
+
+type QuarantinedCoinResponse struct {
+	Ticker        string    `json:"ticker"`
+	QuarantinedAt time.Time `json:"quarantined_at"`
+	Reason        string    `json:"reason"`
+}
+
+func (s *Server) ActivateCoin(c *fiber.Ctx) error {
+	ticker := strings.ToUpper(c.Params("ticker"))
+	if !utils.ValidateCryptoTicker(ticker) {
+		s.log.Sugar().Warnf("Incorrect ticker sent: %s", ticker)
+		return c.Status(fiber.StatusBadRequest).SendString("Incorrect ticker sent")
+	}
+
+	if err := s.rep.ActivateCoin(ticker); err != nil {
+		s.log.Sugar().Errorf("Failed to activate coin %s: %s", ticker, err)
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+type PinCoinRequest struct {
+	Provider string `json:"provider"`
+	Quote    string `json:"quote"`
+}
+
+// PinCoin adds a coin the exchange doesn't trade priced from the provider, e.g. kraken.
+// The provider must quote the coin, the quote asset defaults to USDT.
+func (s *Server) PinCoin(c *fiber.Ctx) error {
+	ticker := strings.ToUpper(c.Params("ticker"))
+	if !utils.ValidateCryptoTicker(ticker) {
+		s.log.Sugar().Warnf("Incorrect ticker sent: %s", ticker)
+		return c.Status(fiber.StatusBadRequest).SendString("Incorrect ticker sent")
+	}
+	var request PinCoinRequest
+	if err := c.BodyParser(&request); err != nil || request.Provider == "" {
+		s.log.Sugar().Warnf("Incorrect pin request sent for %s", ticker)
+		return c.Status(fiber.StatusBadRequest).SendString("Provider is required")
+	}
+	quote := strings.ToUpper(request.Quote)
+	if quote == "" {
+		quote = "USDT"
+	}
+	if _, ok := s.prices.Get(request.Provider); !ok {
+		return c.Status(fiber.StatusBadRequest).SendString("Unknown provider, available: " + strings.Join(s.prices.Names(), ", "))
+	}
+
+	asset := provider.Asset{Symbol: ticker, Quote: quote, Class: provider.Crypto, Source: request.Provider}
+	if _, err := s.prices.LastPrice(asset); err != nil {
+		if errors.Is(err, provider.ErrUnknownAsset) {
+			return c.Status(fiber.StatusNotFound).SendString("Provider doesn't quote the coin")
+		}
+		s.log.Sugar().Errorf("Failed to check %s on %s: %s", asset, request.Provider, err)
+		return c.SendStatus(fiber.StatusBadGateway)
+	}
+
+	pinned, err := s.rep.PinCoin(ticker, quote, request.Provider)
+	if err != nil {
+		s.log.Sugar().Errorf("Failed to pin coin %s: %s", ticker, err)
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+	if !pinned {
+		return c.Status(fiber.StatusConflict).SendString("Coin is traded on the exchange")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/zheka156/market_data/internal/integration/coingecko"
+	"github.com/zheka156/market_data/internal/postgres"
+	"github.com/zheka156/market_data/internal/utils"
+)
+
+func (s *Server) GetCoinGeckoCoins(c *fiber.Ctx) error {
+	coins, err := s.rep.GetCoinGeckoCoins()
+	if err != nil {
+		s.log.Sugar().Errorf("Failed to get coingecko coins: %s", err)
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	response := make([]CoinGeckoCoinResponse, 0, len(coins))
+	for _, coin := range coins {
+		response = append(response, CoinGeckoCoinResponse{
+			Ticker:    coin.Ticker,
+			ID:        coin.CoinGeckoID,
+			Name:      coin.Name,
+			CreatedAt: coin.CreatedAt,
+		})
+	}
+	return c.JSON(response)
+}
+
+type CoinGeckoCoinResponse struct {
+	Ticker    string    `json:"ticker"`
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AddCoinGeckoCoin maps the ticker to the coingecko coin of the id query parameter.
+// Without id the symbol is looked up, several coins under the symbol are returned with 409 to pick one.
+func (s *Server) AddCoinGeckoCoin(c *fiber.Ctx) error {
+	ticker := strings.ToUpper(c.Params("ticker"))
+	if !utils.ValidateCryptoTicker(ticker) {
+		s.log.Sugar().Warnf("Incorrect ticker sent: %s", ticker)
+		return c.Status(fiber.StatusBadRequest).SendString("Incorrect ticker sent")
+	}
+	p, ok := s.prices.Get(coingecko.ProviderName)
+	if !ok {
+		return c.Status(fiber.StatusServiceUnavailable).SendString("Coingecko provider is not registered")
+	}
+	gecko, ok := p.(*coingecko.Provider)
+	if !ok {
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	var coin *coingecko.Coin
+	if id := c.Query("id"); id != "" {
+		found, err := gecko.Coin(id)
+		if errors.Is(err, coingecko.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).SendString("Unknown coingecko id")
+		}
+		if err != nil {
+			s.log.Sugar().Errorf("Failed to look up coingecko coin %s: %s", id, err)
+			return c.SendStatus(fiber.StatusBadGateway)
+		}
+		coin = found
+	} else {
+		candidates, err := gecko.Candidates(ticker)
+		if err != nil {
+			s.log.Sugar().Errorf("Failed to look up coingecko coins of %s: %s", ticker, err)
+			return c.SendStatus(fiber.StatusBadGateway)
+		}
+		switch len(candidates) {
+		case 0:
+			return c.Status(fiber.StatusNotFound).SendString("No coingecko coin with the symbol")
+		case 1:
+			coin = &candidates[0]
+		default:
+			return c.Status(fiber.StatusConflict).JSON(CoinGeckoCandidatesResponse{Ticker: ticker, Candidates: candidates})
+		}
+	}
+
+	err := s.rep.AddCoinGeckoCoin(&postgres.CoinGeckoCoin{Ticker: ticker, CoinGeckoID: coin.ID, Name: coin.Name})
+	if err != nil {
+		s.log.Sugar().Errorf("Failed to add coingecko coin %s: %s", ticker, err)
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+	gecko.Invalidate()
+	return c.JSON(CoinGeckoCoinResponse{Ticker: ticker, ID: coin.ID, Name: coin.Name, CreatedAt: time.Now().UTC()})
+}
+
+type CoinGeckoCandidatesResponse struct {
+	Ticker     string           `json:"ticker"`
+	Candidates []coingecko.Coin `json:"candidates"`
+}
+
+func (s *Server) RemoveCoinGeckoCoin(c *fiber.Ctx) error {
+	ticker := strings.ToUpper(c.Params("ticker"))
+	if !utils.ValidateCryptoTicker(ticker) {
+		s.log.Sugar().Warnf("Incorrect ticker sent: %s", ticker)
+		return c.Status(fiber.StatusBadRequest).SendString("Incorrect ticker sent")
+	}
+
+	if err := s.rep.RemoveCoinGeckoCoin(ticker); err != nil {
+		s.log.Sugar().Errorf("Failed to remove coingecko coin %s: %s", ticker, err)
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+	if p, ok := s.prices.Get(coingecko.ProviderName); ok {
+		if gecko, ok := p.(*coingecko.Provider); ok {
+			gecko.Invalidate()
+		}
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/fx"
+)
+
+var errCurrencyUnsupported = errors.New("currency conversion is not available")
+
+func (s *Server) GetFXRates(c *fiber.Ctx) error {
+	if s.fx == nil {
+		return c.Status(fiber.StatusServiceUnavailable).SendString("FX rates are not configured")
+	}
+	date := time.Now().UTC()
+	if c.Query("date") != "" {
+		parsed, err := time.Parse(time.DateOnly, c.Query("date"))
+		if err != nil {
+			s.log.Sugar().Warnf("Incorrect date sent: %s", c.Query("date"))
+			return c.Status(fiber.StatusBadRequest).SendString("Incorrect date sent, YYYY-MM-DD is expected")
+		}
+		date = parsed
+	}
+
+	response := FXRatesResponse{
+		Base:  fx.USD,
+		Date:  date.Format(time.DateOnly),
+		Rates: make(map[string]decimal.Decimal),
+	}
+	for _, currency := range s.fx.Currencies() {
+		rate, err := s.fx.Rate(currency, date)
+		if err != nil {
+			s.log.Sugar().Errorf("Failed to get %s rate of %s: %s", currency, response.Date, err)
+			continue
+		}
+		response.Rates[currency] = rate
+	}
+	return c.JSON(response)
+}
+
+type FXRatesResponse struct {
+	Base  string                     `json:"base"`
+	Date  string                     `json:"date"`
+	Rates map[string]decimal.Decimal `json:"rates"`
+}
+
+// currencyParam returns the currency query parameter in upper case, empty if values stay in their quote asset
+func (s *Server) currencyParam(c *fiber.Ctx) (string, error) {
+	currency := strings.ToUpper(c.Query("currency"))
+	if currency == "" {
+		return "", nil
+	}
+	if s.fx == nil || !s.fx.Supports(currency) {
+		return "", errCurrencyUnsupported
+	}
+	return currency, nil
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/zheka156/market_data/internal/job"
+	"github.com/zheka156/market_data/internal/postgres"
+)
+
+const (
+	defaultJobRunsLimit = 50
+	maxJobRunsLimit     = 500
+)
+
+func (s *Server) GetJobs(c *fiber.Ctx) error {
+	runs, err := s.rep.GetLastJobRuns()
+	if err != nil {
+		s.log.Sugar().Errorf("Failed to get last job runs: %s", err)
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+	return c.JSON(toJobRunResponses(runs))
+}
+
+func (s *Server) GetJobRuns(c *fiber.Ctx) error {
+	name := c.Params("name")
+
+	limit := c.QueryInt("limit", defaultJobRunsLimit)
+	if limit <= 0 || limit > maxJobRunsLimit {
+		s.log.Sugar().Warnf("Incorrect limit sent: %d", limit)
+		return c.Status(fiber.StatusBadRequest).SendString("Incorrect limit sent")
+	}
+
+	runs, err := s.rep.GetJobRuns(name, limit)
+	if err != nil {
+		s.log.Sugar().Errorf("Failed to get runs of job %s: %s", name, err)
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+	if len(runs) == 0 {
+		return c.Status(fiber.StatusNotFound).SendString("No runs found for job")
+	}
+	return c.JSON(toJobRunResponses(runs))
+}
+
+type JobRunResponse struct {
+	ID               string     `json:"id"`
+	JobName          string     `json:"job_name"`
+	StartedAt        time.Time  `json:"started_at"`
+	FinishedAt       *time.Time `json:"finished_at,omitempty"`
+	Status           string     `json:"status"`
+	SymbolsProcessed int        `json:"symbols_processed"`
+	Error            *string    `json:"error,omitempty"`
+}
+
+func toJobRunResponses(runs []*postgres.JobRun) []JobRunResponse {
+	response := make([]JobRunResponse, 0, len(runs))
+	for _, run := range runs {
+		response = append(response, JobRunResponse{
+			ID:               run.ID.String(),
+			JobName:          run.JobName,
+			StartedAt:        run.StartedAt,
+			FinishedAt:       run.FinishedAt,
+			Status:           run.Status,
+			SymbolsProcessed: run.SymbolsProcessed,
+			Error:            run.Error,
+		})
+	}
+	return response
+}
+
+type TriggerJobRequest struct {
+	Symbols []string `json:"symbols"`
+	DryRun  bool     `json:"dry_run"`
+}
+
+func (s *Server) TriggerJob(c *fiber.Ctx) error {
+	name := c.Params("name")
+
+	var request TriggerJobRequest
+	if len(c.Body()) != 0 {
+		if err := c.BodyParser(&request); err != nil {
+			s.log.Sugar().Warnf("Incorrect trigger request sent: %s", err)
+			return c.Status(fiber.StatusBadRequest).SendString("Incorrect request body sent")
+		}
+	}
+	for i, symbol := range request.Symbols {
+		request.Symbols[i] = strings.ToUpper(strings.TrimSpace(symbol))
+	}
+
+	opts := job.RunOptions{
+		Symbols: request.Symbols,
+		DryRun:  request.DryRun,
+	}
+	// a dry run writes nothing and returns its report, a real run goes to the background
+	if !opts.DryRun {
+		// the run outlives the request, its outcome is polled from the runs of the job
+		run, err := s.jobs.Start(context.WithoutCancel(c.UserContext()), name, opts)
+		if err != nil {
+			return s.triggerError(c, name, err, nil)
+		}
+		c.Location("/admin/jobs/" + name + "/runs")
+		return c.Status(fiber.StatusAccepted).JSON(TriggerJobResponse{RunID: run.ID.String()})
+	}
+
+	report, err := s.jobs.Trigger(c.UserContext(), name, opts)
+	if err != nil {
+		return s.triggerError(c, name, err, report)
+	}
+	return c.JSON(TriggerJobResponse{Report: report})
+}
+
+func (s *Server) triggerError(c *fiber.Ctx, name string, err error, report *job.RunReport) error {
+	switch {
+	case errors.Is(err, job.ErrUnknownJob):
+		return c.Status(fiber.StatusNotFound).SendString("Unknown job, available: " + strings.Join(job.JobNames(), ", "))
+	case errors.Is(err, job.ErrDryRunUnsupported), errors.Is(err, job.ErrSymbolsUnsupported):
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	case errors.Is(err, job.ErrJobRunning):
+		return c.Status(fiber.StatusConflict).SendString("Job is already running")
+	}
+	s.log.Sugar().Errorf("Failed to trigger job %s: %s", name, err)
+	return c.Status(fiber.StatusInternalServerError).JSON(TriggerJobResponse{Report: report, Error: err.Error()})
+}
+
+// TriggerJobResponse has the report of a dry run or the id of a started run
+type TriggerJobResponse struct {
+	RunID  string         `json:"run_id,omitempty"`
+	Report *job.RunReport `json:"report,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
@@ -0,0 +1,248 @@
+package server
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/provider"
+)
+
+const (
+	defaultHistoryPeriod  = 7 * 24 * time.Hour
+	defaultCandleInterval = "1h"
+	defaultCandlesLimit   = 100
+	maxCandlesLimit       = 5000
+)
+
+func (s *Server) GetPriceHistory(c *fiber.Ctx) error {
+	symbol := strings.ToUpper(c.Params("symbol"))
+	quote := strings.ToUpper(c.Query("quote", "USDT"))
+
+	to := time.Now().UTC()
+	if c.Query("to") != "" {
+		parsed, err := time.Parse(time.RFC3339, c.Query("to"))
+		if err != nil {
+			s.log.Sugar().Warnf("Incorrect to date sent: %s", c.Query("to"))
+			return c.Status(fiber.StatusBadRequest).SendString("Incorrect to date sent, RFC3339 is expected")
+		}
+		to = parsed.UTC()
+	}
+	from := to.Add(-defaultHistoryPeriod)
+	if c.Query("from") != "" {
+		parsed, err := time.Parse(time.RFC3339, c.Query("from"))
+		if err != nil {
+			s.log.Sugar().Warnf("Incorrect from date sent: %s", c.Query("from"))
+			return c.Status(fiber.StatusBadRequest).SendString("Incorrect from date sent, RFC3339 is expected")
+		}
+		from = parsed.UTC()
+	}
+	if !from.Before(to) {
+		return c.Status(fiber.StatusBadRequest).SendString("from date should be before to date")
+	}
+	currency, err := s.currencyParam(c)
+	if err != nil || (currency != "" && !s.fx.IsUSD(quote)) {
+		return c.Status(fiber.StatusBadRequest).SendString("Unsupported currency")
+	}
+
+	prices, err := s.rep.GetPriceHistory(symbol, quote, from, to)
+	if err != nil {
+		s.log.Sugar().Errorf("Failed to get price history for %s/%s: %s", symbol, quote, err)
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	response := PriceHistoryResponse{
+		Symbol: symbol,
+		Prices: make([]PricePoint, 0, len(prices)),
+	}
+	if currency != "" {
+		if err := s.fx.Preload(from, to); err != nil {
+			s.log.Sugar().Warnf("Failed to preload fx rates: %s", err)
+		}
+	}
+	for _, p := range prices {
+		point := PricePoint{
+			Quote: p.Tosymbol,
+			Price: p.Last_price,
+			TS:    p.TS,
+		}
+		if currency != "" {
+			point.Quote = currency
+			converted, err := s.fx.Convert(p.Last_price, currency, p.TS)
+			if err != nil {
+				s.log.Sugar().Errorf("Failed to convert %s price to %s: %s", symbol, currency, err)
+				return c.SendStatus(fiber.StatusBadGateway)
+			}
+			point.Price = converted.Truncate(8)
+		}
+		response.Prices = append(response.Prices, point)
+	}
+	return c.JSON(response)
+}
+
+type PriceHistoryResponse struct {
+	Symbol string       `json:"symbol"`
+	Prices []PricePoint `json:"prices"`
+}
+
+type PricePoint struct {
+	Quote string          `json:"quote"`
+	Price decimal.Decimal `json:"price"`
+	TS    time.Time       `json:"ts"`
+}
+
+func (s *Server) GetCandles(c *fiber.Ctx) error {
+	symbol := strings.ToUpper(c.Params("symbol"))
+	quote := strings.ToUpper(c.Query("quote", "USDT"))
+	intervalName := c.Query("interval", defaultCandleInterval)
+
+	interval, err := provider.ParseInterval(intervalName)
+	if err != nil {
+		s.log.Sugar().Warnf("Incorrect interval sent: %s", intervalName)
+		return c.Status(fiber.StatusBadRequest).SendString("Incorrect interval sent")
+	}
+	currency, err := s.currencyParam(c)
+	if err != nil || (currency != "" && !s.fx.IsUSD(quote)) {
+		return c.Status(fiber.StatusBadRequest).SendString("Unsupported currency")
+	}
+	limit := c.QueryInt("limit", defaultCandlesLimit)
+	if limit <= 0 || limit > maxCandlesLimit {
+		s.log.Sugar().Warnf("Incorrect limit sent: %d", limit)
+		return c.Status(fiber.StatusBadRequest).SendString("Incorrect limit sent")
+	}
+	var from, to time.Time
+	for name, target := range map[string]*time.Time{"from": &from, "to": &to} {
+		if c.Query(name) == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, c.Query(name))
+		if err != nil {
+			s.log.Sugar().Warnf("Incorrect %s date sent: %s", name, c.Query(name))
+			return c.Status(fiber.StatusBadRequest).SendString("Incorrect " + name + " date sent, RFC3339 is expected")
+		}
+		*target = parsed.UTC()
+	}
+	// the window spans limit candles from the bound that is set, the latest ones by default.
+	// The latest window ends at the open of the current candle, providers serve candles
+	// opened at or after from.
+	span := time.Duration(limit-1) * interval
+	switch {
+	case from.IsZero() && to.IsZero():
+		to = time.Now().UTC().Truncate(interval)
+		from = to.Add(-span)
+	case from.IsZero():
+		from = to.Add(-span)
+	case to.IsZero():
+		to = from.Add(span)
+	}
+
+	asset := provider.Asset{Symbol: symbol, Quote: quote, Class: provider.Crypto}
+	candles, err := s.prices.History(asset, from, to, interval)
+	if errors.Is(err, provider.ErrUnknownAsset) {
+		return c.Status(fiber.StatusNotFound).SendString("Unknown symbol")
+	}
+	if err != nil {
+		s.log.Sugar().Errorf("Failed to get candles for %s: %s", asset, err)
+		return c.SendStatus(fiber.StatusBadGateway)
+	}
+	if len(candles) > limit {
+		candles = candles[:limit]
+	}
+
+	response := CandlesResponse{
+		Symbol:   symbol,
+		Quote:    quote,
+		Interval: intervalName,
+		Candles:  make([]Candle, 0, len(candles)),
+	}
+	if currency != "" {
+		response.Quote = currency
+		if err := s.fx.Preload(from, to); err != nil {
+			s.log.Sugar().Warnf("Failed to preload fx rates: %s", err)
+		}
+	}
+	for _, k := range candles {
+		candle := Candle{
+			OpenTime: k.OpenTime,
+			Open:     k.Open,
+			High:     k.High,
+			Low:      k.Low,
+			Close:    k.Close,
+			Volume:   k.Volume,
+		}
+		if currency != "" {
+			// a candle is converted at the rate of its open time
+			rate, err := s.fx.Rate(currency, k.OpenTime)
+			if err != nil {
+				s.log.Sugar().Errorf("Failed to convert %s candles to %s: %s", asset, currency, err)
+				return c.SendStatus(fiber.StatusBadGateway)
+			}
+			candle.Open = candle.Open.Mul(rate).Truncate(8)
+			candle.High = candle.High.Mul(rate).Truncate(8)
+			candle.Low = candle.Low.Mul(rate).Truncate(8)
+			candle.Close = candle.Close.Mul(rate).Truncate(8)
+		}
+		response.Candles = append(response.Candles, candle)
+	}
+	return c.JSON(response)
+}
+
+type CandlesResponse struct {
+	Symbol   string   `json:"symbol"`
+	Quote    string   `json:"quote"`
+	Interval string   `json:"interval"`
+	Candles  []Candle `json:"candles"`
+}
+
+type Candle struct {
+	OpenTime time.Time       `json:"open_time"`
+	Open     decimal.Decimal `json:"open"`
+	High     decimal.Decimal `json:"high"`
+	Low      decimal.Decimal `json:"low"`
+	Close    decimal.Decimal `json:"close"`
+	Volume   decimal.Decimal `json:"volume"`
+}
+
+func (s *Server) GetLivePrice(c *fiber.Ctx) error {
+	symbol := strings.ToUpper(c.Params("symbol"))
+
+	currency, err := s.currencyParam(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Unsupported currency")
+	}
+
+	update, ok := s.feed.Last(symbol)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).SendString("No live price for symbol")
+	}
+	response := LivePriceResponse{
+		Symbol: update.Symbol,
+		Quote:  update.Quote,
+		Price:  update.Price,
+		TS:     update.Time,
+		Source: update.Source,
+	}
+	if currency != "" {
+		if !s.fx.IsUSD(update.Quote) {
+			return c.Status(fiber.StatusBadRequest).SendString("Live price is not valued in USD")
+		}
+		response.Quote = currency
+		converted, err := s.fx.Convert(update.Price, currency, update.Time)
+		if err != nil {
+			s.log.Sugar().Errorf("Failed to convert %s live price to %s: %s", symbol, currency, err)
+			return c.SendStatus(fiber.StatusBadGateway)
+		}
+		response.Price = converted.Truncate(8)
+	}
+	return c.JSON(response)
+}
+
+type LivePriceResponse struct {
+	Symbol string          `json:"symbol"`
+	Quote  string          `json:"quote"`
+	Price  decimal.Decimal `json:"price"`
+	TS     time.Time       `json:"ts"`
+	Source string          `json:"source"`
+}
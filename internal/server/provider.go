@@ -0,0 +1,59 @@
+package server
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetProviderHealth returns the circuit state, success rate and latency of every provider
+// over its recent calls and how old its newest price is
+func (s *Server) GetProviderHealth(c *fiber.Ctx) error {
+	now := time.Now()
+	health := s.prices.Health()
+	response := make([]ProviderHealthResponse, 0, len(health))
+	for _, h := range health {
+		r := ProviderHealthResponse{
+			Provider:     h.Name,
+			State:        string(h.State),
+			Score:        h.Score,
+			Calls:        h.Calls,
+			Failures:     h.Failures,
+			AvgLatencyMs: h.AvgLatency.Milliseconds(),
+			LastQuoteAt:  optionalTime(h.LastQuote),
+			LastSuccess:  optionalTime(h.LastSuccess),
+			LastFailure:  optionalTime(h.LastFailure),
+			LastError:    h.LastError,
+			OpenedAt:     optionalTime(h.OpenedAt),
+		}
+		if !h.LastQuote.IsZero() {
+			staleness := int64(now.Sub(h.LastQuote).Seconds())
+			r.StalenessSeconds = &staleness
+		}
+		response = append(response, r)
+	}
+	return c.JSON(response)
+}
+
+// ProviderHealthResponse covers the recent calls of a provider, Score is the share of them that succeeded
+type ProviderHealthResponse struct {
+	Provider         string     `json:"provider"`
+	State            string     `json:"state"`
+	Score            float64    `json:"score"`
+	Calls            int        `json:"calls"`
+	Failures         int        `json:"failures"`
+	AvgLatencyMs     int64      `json:"avg_latency_ms"`
+	LastQuoteAt      *time.Time `json:"last_quote_at,omitempty"`
+	StalenessSeconds *int64     `json:"staleness_seconds,omitempty"`
+	LastSuccess      *time.Time `json:"last_success,omitempty"`
+	LastFailure      *time.Time `json:"last_failure,omitempty"`
+	LastError        string     `json:"last_error,omitempty"`
+	OpenedAt         *time.Time `json:"opened_at,omitempty"`
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -1,7 +1,33 @@
 package server
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/zheka156/market_data/internal/middleware"
+)
 
 func (s *Server) InitRoutes(router *fiber.App) {
 	router.Get("/previousDateQuotes/:ticker", s.GetStockLastPrice)
+	router.Get("/prices/:symbol/history", s.GetPriceHistory)
+	router.Get("/prices/:symbol/candles", s.GetCandles)
+	router.Get("/prices/:symbol/live", s.GetLivePrice)
+	router.Get("/stocks/search", s.SearchStocks)
+	router.Get("/stocks/:ticker/history", s.GetStockPriceHistory)
+	router.Get("/stocks/:ticker/bars", s.GetStockBars)
+	router.Get("/stocks/:ticker/details", s.GetStockDetails)
+	router.Get("/stocks/:ticker/previous-close", s.GetStockPreviousClose)
+	router.Get("/fx/rates", s.GetFXRates)
+
+	admin := router.Group("/admin", middleware.AdminAuth(s.log))
+	admin.Get("/jobs", s.GetJobs)
+	admin.Get("/jobs/:name/runs", s.GetJobRuns)
+	admin.Post("/jobs/:name/trigger", s.TriggerJob)
+	admin.Get("/providers/health", s.GetProviderHealth)
+	admin.Get("/coins/quarantined", s.GetQuarantinedCoins)
+	admin.Post("/coins/:ticker/activate", s.ActivateCoin)
+	admin.Post("/coins/:ticker/source", s.PinCoin)
+	admin.Get("/coingecko/coins", s.GetCoinGeckoCoins)
+	admin.Post("/coingecko/coins/:ticker", s.AddCoinGeckoCoin)
+	admin.Delete("/coingecko/coins/:ticker", s.RemoveCoinGeckoCoin)
+	admin.Post("/stocks/:ticker", s.AddStockToWatchlist)
+	admin.Delete("/stocks/:ticker", s.RemoveStockFromWatchlist)
 }
@@ -1,25 +1,31 @@
 package server
 
 import (
-	"github.com/zheka156/market_data/internal/integration/binance"
-	"github.com/zheka156/market_data/internal/integration/polygon"
+	"github.com/zheka156/market_data/internal/fx"
+	"github.com/zheka156/market_data/internal/job"
 	"github.com/zheka156/market_data/internal/postgres"
+	"github.com/zheka156/market_data/internal/pricefeed"
+	"github.com/zheka156/market_data/internal/provider"
 	"go.uber.org/zap"
 )
 
 type Server struct {
-	polygonClient *polygon.Client
-	binanceClient *binance.Client
-	rep postgres.Repository
-	log *zap.Logger
+	prices *provider.Registry
+	rep    postgres.Repository
+	jobs   *job.JobParams
+	feed   *pricefeed.Feed
+	fx     *fx.Service
+	log    *zap.Logger
 }
 
-func NewServer(polygonClient *polygon.Client, 
-	binanceClient *binance.Client, db postgres.Repository, logger *zap.Logger) *Server {
+func NewServer(prices *provider.Registry, db postgres.Repository, jobs *job.JobParams, feed *pricefeed.Feed,
+	rates *fx.Service, logger *zap.Logger) *Server {
 	return &Server{
-		polygonClient: polygonClient,
-		binanceClient: binanceClient,
-		rep: db,
-		log: logger,
+		prices: prices,
+		rep:    db,
+		jobs:   jobs,
+		feed:   feed,
+		fx:     rates,
+		log:    logger,
 	}
-}
\ No newline at end of file
+}
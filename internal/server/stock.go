@@ -1,33 +1,340 @@
 package server
 
 import (
+	"errors"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/integration/polygon"
+	"github.com/zheka156/market_data/internal/provider"
 	"github.com/zheka156/market_data/internal/utils"
 )
 
 func (s *Server) GetStockLastPrice(c *fiber.Ctx) error {
-	ticker := c.Params("ticker")
+	ticker := strings.ToUpper(c.Params("ticker"))
 
-	if !utils.ValidateTicker(ticker) {
+	if !utils.ValidateStockTicker(ticker) {
 		s.log.Sugar().Warnf("Incorrect ticker sent: %s", ticker)
 		return c.Status(fiber.StatusBadRequest).SendString("Incorrect ticker sent")
 	}
 
-	resp := s.polygonClient.GetLastDatePrices(ticker)
-	if resp.Status != "OK" {
+	// stored watchlist prices don't spend polygon quota, an older one than the last session is stale
+	stored, err := s.rep.GetLastStockPrice(ticker)
+	if err != nil {
+		s.log.Sugar().Errorf("Failed to get stored stock price for %s: %s", ticker, err)
+	}
+	if stored != nil && stored.SessionDate.Format(time.DateOnly) == lastCompletedSession(time.Now()).Format(time.DateOnly) {
+		last, _ := stored.Close.Float64()
+		return c.JSON(LastPriceResponse{
+			Ticker:        ticker,
+			Last:          last,
+			RequestedDate: stored.SessionDate.Format(time.DateOnly),
+		})
+	}
+
+	quote, err := s.prices.LastPrice(stockAsset(ticker))
+	if errors.Is(err, provider.ErrUnknownAsset) {
+		return c.Status(fiber.StatusNotFound).SendString("Unknown ticker")
+	}
+	if err != nil {
+		s.log.Sugar().Errorf("Failed to get last stock price for %s: %s", ticker, err)
 		return c.SendStatus(fiber.StatusInternalServerError)
 	}
+	last, _ := quote.Price.Float64()
 	responseMessage := LastPriceResponse{
-		Ticker: ticker,
-		Last:   resp.Close,
-		RequestedDate:  resp.From,
+		Ticker:        ticker,
+		Last:          last,
+		RequestedDate: quote.Time.Format(time.DateOnly),
 	}
 	return c.JSON(responseMessage)
 }
 
+// stockSessionCloseHour is the UTC hour the US market has closed by, 16:00 ET is 20:00 or 21:00 UTC
+const stockSessionCloseHour = 21
+
+// lastCompletedSession returns the last weekday whose session has closed by now, market holidays
+// are not known, so a stored price of the session before a holiday is not served on the next day
+func lastCompletedSession(now time.Time) time.Time {
+	now = now.UTC()
+	day := now.Truncate(24 * time.Hour)
+	if now.Hour() < stockSessionCloseHour {
+		day = day.AddDate(0, 0, -1)
+	}
+	for day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day
+}
+
 type LastPriceResponse struct {
-	Ticker string  `json:"ticker"`
-	Last   float64 `json:"last"`
-	RequestedDate  string  `json:"from"`
+	Ticker        string  `json:"ticker"`
+	Last          float64 `json:"last"`
+	RequestedDate string  `json:"from"`
+}
+
+func (s *Server) GetStockPriceHistory(c *fiber.Ctx) error {
+	ticker := strings.ToUpper(c.Params("ticker"))
+
+	if !utils.ValidateStockTicker(ticker) {
+		s.log.Sugar().Warnf("Incorrect ticker sent: %s", ticker)
+		return c.Status(fiber.StatusBadRequest).SendString("Incorrect ticker sent")
+	}
+
+	to := time.Now().UTC()
+	if c.Query("to") != "" {
+		parsed, err := time.Parse(time.DateOnly, c.Query("to"))
+		if err != nil {
+			s.log.Sugar().Warnf("Incorrect to date sent: %s", c.Query("to"))
+			return c.Status(fiber.StatusBadRequest).SendString("Incorrect to date sent, YYYY-MM-DD is expected")
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, -1, 0)
+	if c.Query("from") != "" {
+		parsed, err := time.Parse(time.DateOnly, c.Query("from"))
+		if err != nil {
+			s.log.Sugar().Warnf("Incorrect from date sent: %s", c.Query("from"))
+			return c.Status(fiber.StatusBadRequest).SendString("Incorrect from date sent, YYYY-MM-DD is expected")
+		}
+		from = parsed
+	}
+	if !from.Before(to) {
+		return c.Status(fiber.StatusBadRequest).SendString("from date should be before to date")
+	}
+
+	prices, err := s.rep.GetStockPriceHistory(ticker, from, to)
+	if err != nil {
+		s.log.Sugar().Errorf("Failed to get stock price history for %s: %s", ticker, err)
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	response := StockHistoryResponse{
+		Ticker: ticker,
+		Bars:   make([]StockBar, 0, len(prices)),
+	}
+	for _, p := range prices {
+		response.Bars = append(response.Bars, StockBar{
+			Date:   p.SessionDate.Format(time.DateOnly),
+			Open:   p.Open,
+			High:   p.High,
+			Low:    p.Low,
+			Close:  p.Close,
+			Volume: p.Volume,
+		})
+	}
+	return c.JSON(response)
+}
+
+type StockHistoryResponse struct {
+	Ticker string     `json:"ticker"`
+	Bars   []StockBar `json:"bars"`
+}
+
+type StockBar struct {
+	Date   string          `json:"date"`
+	Open   decimal.Decimal `json:"open"`
+	High   decimal.Decimal `json:"high"`
+	Low    decimal.Decimal `json:"low"`
+	Close  decimal.Decimal `json:"close"`
+	Volume decimal.Decimal `json:"volume"`
+}
+
+// AddStockToWatchlist adds tickers polygon knows, the check is skipped if polygon is not registered
+func (s *Server) AddStockToWatchlist(c *fiber.Ctx) error {
+	ticker := strings.ToUpper(c.Params("ticker"))
+
+	if !utils.ValidateStockTicker(ticker) {
+		s.log.Sugar().Warnf("Incorrect ticker sent: %s", ticker)
+		return c.Status(fiber.StatusBadRequest).SendString("Incorrect ticker sent")
+	}
+	if stocks, ok := s.polygon(); ok {
+		_, err := stocks.Details(ticker)
+		if errors.Is(err, polygon.ErrUnknownTicker) {
+			return c.Status(fiber.StatusNotFound).SendString("Unknown ticker")
+		}
+		if err != nil {
+			s.log.Sugar().Errorf("Failed to validate stock %s: %s", ticker, err)
+			return c.SendStatus(fiber.StatusBadGateway)
+		}
+	}
+	if err := s.rep.AddStock(ticker); err != nil {
+		s.log.Sugar().Errorf("Failed to add stock %s: %s", ticker, err)
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (s *Server) RemoveStockFromWatchlist(c *fiber.Ctx) error {
+	ticker := strings.ToUpper(c.Params("ticker"))
+
+	if !utils.ValidateStockTicker(ticker) {
+		s.log.Sugar().Warnf("Incorrect ticker sent: %s", ticker)
+		return c.Status(fiber.StatusBadRequest).SendString("Incorrect ticker sent")
+	}
+	if err := s.rep.RemoveStock(ticker); err != nil {
+		s.log.Sugar().Errorf("Failed to remove stock %s: %s", ticker, err)
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// polygon returns the registered polygon provider for ticker details
+func (s *Server) polygon() (*polygon.Provider, bool) {
+	p, ok := s.prices.Get(polygon.ProviderName)
+	if !ok {
+		return nil, false
+	}
+	stocks, ok := p.(*polygon.Provider)
+	return stocks, ok
+}
+
+func (s *Server) GetStockDetails(c *fiber.Ctx) error {
+	ticker := strings.ToUpper(c.Params("ticker"))
+	if !utils.ValidateStockTicker(ticker) {
+		s.log.Sugar().Warnf("Incorrect ticker sent: %s", ticker)
+		return c.Status(fiber.StatusBadRequest).SendString("Incorrect ticker sent")
+	}
+	stocks, ok := s.polygon()
+	if !ok {
+		return c.Status(fiber.StatusServiceUnavailable).SendString("Polygon provider is not registered")
+	}
+
+	details, err := stocks.Details(ticker)
+	if errors.Is(err, polygon.ErrUnknownTicker) {
+		return c.Status(fiber.StatusNotFound).SendString("Unknown ticker")
+	}
+	if err != nil {
+		s.log.Sugar().Errorf("Failed to get stock details for %s: %s", ticker, err)
+		return c.SendStatus(fiber.StatusBadGateway)
+	}
+	return c.JSON(details)
+}
+
+func (s *Server) SearchStocks(c *fiber.Ctx) error {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		return c.Status(fiber.StatusBadRequest).SendString("Search query is required")
+	}
+	limit := c.QueryInt("limit", defaultStockSearchLimit)
+	if limit <= 0 {
+		s.log.Sugar().Warnf("Incorrect limit sent: %d", limit)
+		return c.Status(fiber.StatusBadRequest).SendString("Incorrect limit sent")
+	}
+	stocks, ok := s.polygon()
+	if !ok {
+		return c.Status(fiber.StatusServiceUnavailable).SendString("Polygon provider is not registered")
+	}
+
+	results, err := stocks.Search(query, limit)
+	if err != nil {
+		s.log.Sugar().Errorf("Failed to search stocks for %q: %s", query, err)
+		return c.SendStatus(fiber.StatusBadGateway)
+	}
+	return c.JSON(StockSearchResponse{Query: query, Results: results})
+}
+
+const defaultStockSearchLimit = 10
+
+type StockSearchResponse struct {
+	Query   string                  `json:"query"`
+	Results []polygon.TickerDetails `json:"results"`
+}
+
+func (s *Server) GetStockPreviousClose(c *fiber.Ctx) error {
+	ticker := strings.ToUpper(c.Params("ticker"))
+	if !utils.ValidateStockTicker(ticker) {
+		s.log.Sugar().Warnf("Incorrect ticker sent: %s", ticker)
+		return c.Status(fiber.StatusBadRequest).SendString("Incorrect ticker sent")
+	}
+	stocks, ok := s.polygon()
+	if !ok {
+		return c.Status(fiber.StatusServiceUnavailable).SendString("Polygon provider is not registered")
+	}
+
+	bar, err := stocks.PreviousClose(ticker)
+	if errors.Is(err, provider.ErrUnknownAsset) {
+		return c.Status(fiber.StatusNotFound).SendString("No previous session for ticker")
+	}
+	if err != nil {
+		s.log.Sugar().Errorf("Failed to get previous close for %s: %s", ticker, err)
+		return c.SendStatus(fiber.StatusBadGateway)
+	}
+	return c.JSON(StockBar{
+		Date:   bar.OpenTime.Format(time.DateOnly),
+		Open:   bar.Open,
+		High:   bar.High,
+		Low:    bar.Low,
+		Close:  bar.Close,
+		Volume: bar.Volume,
+	})
+}
+
+// GetStockBars returns bars of the range from polygon, stored history is served by GetStockPriceHistory
+func (s *Server) GetStockBars(c *fiber.Ctx) error {
+	ticker := strings.ToUpper(c.Params("ticker"))
+	if !utils.ValidateStockTicker(ticker) {
+		s.log.Sugar().Warnf("Incorrect ticker sent: %s", ticker)
+		return c.Status(fiber.StatusBadRequest).SendString("Incorrect ticker sent")
+	}
+	intervalName := c.Query("interval", "1d")
+	interval, err := provider.ParseInterval(intervalName)
+	if err != nil {
+		s.log.Sugar().Warnf("Incorrect interval sent: %s", intervalName)
+		return c.Status(fiber.StatusBadRequest).SendString("Incorrect interval sent")
+	}
+
+	to := time.Now().UTC()
+	from := to.AddDate(0, -1, 0)
+	for name, target := range map[string]*time.Time{"from": &from, "to": &to} {
+		if c.Query(name) == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, c.Query(name))
+		if err != nil {
+			s.log.Sugar().Warnf("Incorrect %s date sent: %s", name, c.Query(name))
+			return c.Status(fiber.StatusBadRequest).SendString("Incorrect " + name + " date sent, RFC3339 is expected")
+		}
+		*target = parsed.UTC()
+	}
+	if !from.Before(to) {
+		return c.Status(fiber.StatusBadRequest).SendString("from date should be before to date")
+	}
+	// the range is capped like the candles limit, polygon pages a longer one request after request
+	if to.Sub(from)/interval > maxCandlesLimit {
+		s.log.Sugar().Warnf("Too long range sent: %s - %s by %s", from, to, intervalName)
+		return c.Status(fiber.StatusBadRequest).SendString("Range exceeds the bars limit of the interval")
+	}
+
+	candles, err := s.prices.History(stockAsset(ticker), from, to, interval)
+	if errors.Is(err, provider.ErrUnsupported) {
+		return c.Status(fiber.StatusBadRequest).SendString("Unsupported interval")
+	}
+	if err != nil {
+		s.log.Sugar().Errorf("Failed to get stock bars for %s: %s", ticker, err)
+		return c.SendStatus(fiber.StatusBadGateway)
+	}
+
+	response := CandlesResponse{
+		Symbol:   ticker,
+		Quote:    "USD",
+		Interval: intervalName,
+		Candles:  make([]Candle, 0, len(candles)),
+	}
+	for _, k := range candles {
+		response.Candles = append(response.Candles, Candle{
+			OpenTime: k.OpenTime,
+			Open:     k.Open,
+			High:     k.High,
+			Low:      k.Low,
+			Close:    k.Close,
+			Volume:   k.Volume,
+		})
+	}
+	return c.JSON(response)
+}
+
+func stockAsset(ticker string) provider.Asset {
+	return provider.Asset{Symbol: ticker, Quote: "USD", Class: provider.Stock}
 }
@@ -12,6 +12,18 @@ func ValidateTicker(ticker string) bool {
 	return valid.MatchString(ticker)
 }
 
+// ValidateCryptoTicker accepts catalog coin tickers, e.g. 1INCH or RENDER
+func ValidateCryptoTicker(ticker string) bool {
+	var valid = regexp.MustCompile(`^[A-Z0-9]{1,10}$`)
+	return valid.MatchString(ticker)
+}
+
+// ValidateStockTicker accepts share class suffixes, e.g. BRK.B
+func ValidateStockTicker(ticker string) bool {
+	var valid = regexp.MustCompile(`^[A-Z]{1,5}([.-][A-Z]{1,2})?$`)
+	return valid.MatchString(ticker)
+}
+
 func RemoveDuplicates(input []string) []string {
 	inputMap := make(map[string]bool)
 	output := []string{}
@@ -24,9 +36,8 @@ func RemoveDuplicates(input []string) []string {
 	return output
 }
 
-func StripUSDT(ticker string) string {
-	return ticker[:len(ticker)-4]
-}
+// USDT is the quote asset coins are valued in
+const USDT = "USDT"
 
 func CreateCoinRegexp(coins []string) (*regexp.Regexp, error) {
 	pattern := `(?i)\b(` + strings.Join(coins, "|") + `)\b`
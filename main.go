@@ -2,20 +2,31 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/zheka156/market_data/internal/alert"
 	newLogger "github.com/zheka156/market_data/internal/common/log"
 	"github.com/zheka156/market_data/internal/config"
+	"github.com/zheka156/market_data/internal/fx"
 	"github.com/zheka156/market_data/internal/integration/binance"
+	"github.com/zheka156/market_data/internal/integration/coinbase"
+	"github.com/zheka156/market_data/internal/integration/coingecko"
+	"github.com/zheka156/market_data/internal/integration/frankfurter"
+	"github.com/zheka156/market_data/internal/integration/kraken"
+	"github.com/zheka156/market_data/internal/integration/openerapi"
 	"github.com/zheka156/market_data/internal/integration/polygon"
 	"github.com/zheka156/market_data/internal/integration/telegram"
 	"github.com/zheka156/market_data/internal/job"
+	"github.com/zheka156/market_data/internal/leader"
 	"github.com/zheka156/market_data/internal/middleware"
 	"github.com/zheka156/market_data/internal/postgres"
+	"github.com/zheka156/market_data/internal/pricefeed"
+	"github.com/zheka156/market_data/internal/provider"
 	"github.com/zheka156/market_data/internal/server"
 	"go.uber.org/zap"
 )
@@ -35,18 +46,62 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	webApp := middleware.New(logger)
-
 	polygonClient := polygon.NewClient(logger)
 	binanceClient := binance.NewClient(logger, config)
+	coinbaseClient := coinbase.NewClient(logger, config)
+	krakenClient := kraken.NewClient(logger, config)
+	coingeckoClient := coingecko.NewClient(logger, config)
 	dbClient := postgres.NewClient(logger)
 
-	server := server.NewServer(polygonClient, binanceClient, dbClient, logger)
+	binanceProvider := binance.NewProvider(logger, binanceClient)
+	binanceProvider.SetVolume(config.Providers.Consensus.Enabled && config.Providers.Consensus.Method == provider.MethodVolumeWeighted)
+	polygonProvider := polygon.NewProvider(logger, polygonClient)
+	prices, err := provider.NewRegistry(logger, config.Providers,
+		binanceProvider,
+		coinbase.NewProvider(logger, coinbaseClient, config.Coinbase),
+		kraken.NewProvider(logger, krakenClient, config.Kraken),
+		coingecko.NewProvider(logger, coingeckoClient, dbClient, config.CoinGecko),
+		polygonProvider,
+	)
+	if err != nil {
+		log.Fatalf("can't initialize price providers: %s", err)
+	}
+	polygonProvider.SetTracker(prices.Tracker(polygon.ProviderName))
+
+	fxSource, err := newFXSource(logger, config)
+	if err != nil {
+		log.Fatalf("can't initialize fx rates: %s", err)
+	}
+	rates := fx.NewService(logger, fxSource, dbClient, config.FX)
+
+	jobParams := job.NewJobParams(logger, binanceClient, prices, dbClient, alert.NewNotifier(logger, config.Alerts), rates, config.Jobs)
+
+	if len(os.Args) > 1 && os.Args[1] == runJobCommand {
+		code := runJob(ctx, jobParams, os.Args[2:])
+		logger.Sync()
+		os.Exit(code)
+	}
+
+	webApp := middleware.New(logger)
+
+	feed := pricefeed.New(logger)
+	if config.Binance.Stream.Enabled {
+		go startBinanceStream(ctx, logger, config, dbClient, feed)
+	}
+
+	server := server.NewServer(prices, dbClient, jobParams, feed, rates, logger)
 	server.InitRoutes(webApp)
 
-	go job.HourJob(*job.NewJobParams(logger, binanceClient, dbClient))
+	elector := leader.NewElector(logger, dbClient.NewAdvisoryLock(config.Leader.LockKey), config.Leader)
+	jobsDone := make(chan struct{})
+	go func() {
+		defer close(jobsDone)
+		elector.Run(ctx, func(ctx context.Context) {
+			job.RunScheduled(ctx, *jobParams)
+		})
+	}()
 
-	go telegram.NewBot(ctx, logger, dbClient)
+	go telegram.NewBot(ctx, logger, dbClient, prices, rates)
 
 	port := os.Getenv("PORT")
 	go func() {
@@ -70,6 +125,18 @@ func main() {
 	}
 
 	cancel()
+	<-jobsDone
 	logger.Info("Bot is shut down")
 
 }
+
+// newFXSource returns the fiat rates source of the configuration, frankfurter by default
+func newFXSource(logger *zap.Logger, conf *config.Config) (fx.Source, error) {
+	switch conf.FX.Provider {
+	case "", frankfurter.SourceName:
+		return frankfurter.NewClient(logger, conf), nil
+	case openerapi.SourceName:
+		return openerapi.NewClient(logger, conf), nil
+	}
+	return nil, fmt.Errorf("unknown fx provider %s", conf.FX.Provider)
+}
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE job_runs(
+    id UUID PRIMARY KEY,
+    job_name VARCHAR(50) NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP,
+    status VARCHAR(20) NOT NULL,
+    symbols_processed INTEGER NOT NULL DEFAULT 0,
+    error TEXT
+);
+
+CREATE INDEX job_runs_job_name_started_at_idx ON job_runs (job_name, started_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS job_runs;
+-- +goose StatementEnd
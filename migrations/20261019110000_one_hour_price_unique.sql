@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE one_hour_price ADD COLUMN source_ts TIMESTAMP;
+UPDATE one_hour_price SET source_ts = ts;
+
+DELETE FROM one_hour_price WHERE fromsym IS NULL OR tosym IS NULL OR ts IS NULL;
+
+-- keep only the latest row of every hour bucket
+DELETE FROM one_hour_price p
+USING one_hour_price d
+WHERE p.fromsym = d.fromsym
+    AND p.tosym = d.tosym
+    AND date_trunc('hour', p.ts) = date_trunc('hour', d.ts)
+    AND (p.ts < d.ts OR (p.ts = d.ts AND p.ctid < d.ctid));
+
+UPDATE one_hour_price SET ts = date_trunc('hour', ts);
+
+ALTER TABLE one_hour_price
+    ALTER COLUMN fromsym SET NOT NULL,
+    ALTER COLUMN tosym SET NOT NULL,
+    ALTER COLUMN ts SET NOT NULL,
+    ALTER COLUMN source_ts SET NOT NULL,
+    ADD CONSTRAINT one_hour_price_fromsym_tosym_ts_key UNIQUE (fromsym, tosym, ts);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE one_hour_price
+    DROP CONSTRAINT IF EXISTS one_hour_price_fromsym_tosym_ts_key,
+    ALTER COLUMN fromsym DROP NOT NULL,
+    ALTER COLUMN tosym DROP NOT NULL,
+    ALTER COLUMN ts DROP NOT NULL,
+    DROP COLUMN IF EXISTS source_ts;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE one_day_price(
+    fromsym VARCHAR(10) NOT NULL,
+    tosym VARCHAR(10) NOT NULL,
+    ts TIMESTAMP NOT NULL,
+    open NUMERIC(20, 8) NOT NULL,
+    high NUMERIC(20, 8) NOT NULL,
+    low NUMERIC(20, 8) NOT NULL,
+    close NUMERIC(20, 8) NOT NULL,
+    samples INTEGER NOT NULL,
+    UNIQUE (fromsym, tosym, ts)
+);
+
+CREATE INDEX one_hour_price_ts_idx ON one_hour_price (ts);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS one_hour_price_ts_idx;
+DROP TABLE IF EXISTS one_day_price;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE stock(
+    ticker VARCHAR(10) NOT NULL PRIMARY KEY,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE stock_daily_price(
+    ticker VARCHAR(10) NOT NULL,
+    session_date DATE NOT NULL,
+    open NUMERIC(20, 8) NOT NULL,
+    high NUMERIC(20, 8) NOT NULL,
+    low NUMERIC(20, 8) NOT NULL,
+    close NUMERIC(20, 8) NOT NULL,
+    volume NUMERIC NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    UNIQUE (ticker, session_date)
+);
+
+INSERT INTO stock (ticker)
+VALUES ('AAPL'), ('MSFT'), ('NVDA'), ('AMZN'), ('GOOGL'), ('TSLA')
+ON CONFLICT (ticker) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS stock_daily_price;
+DROP TABLE IF EXISTS stock;
+-- +goose StatementEnd
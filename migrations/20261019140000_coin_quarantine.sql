@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE coin
+    ADD COLUMN is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN quarantined_at TIMESTAMP,
+    ADD COLUMN quarantine_reason TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE coin
+    DROP COLUMN IF EXISTS is_active,
+    DROP COLUMN IF EXISTS quarantined_at,
+    DROP COLUMN IF EXISTS quarantine_reason;
+-- +goose StatementEnd
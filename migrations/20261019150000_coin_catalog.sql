@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE coin
+    ADD COLUMN quote_asset VARCHAR(10) NOT NULL DEFAULT 'USDT',
+    ADD COLUMN step_precision INTEGER NOT NULL DEFAULT 8,
+    ADD COLUMN is_tradeable BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN is_visible BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN synced_at TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE coin
+    DROP COLUMN IF EXISTS quote_asset,
+    DROP COLUMN IF EXISTS step_precision,
+    DROP COLUMN IF EXISTS is_tradeable,
+    DROP COLUMN IF EXISTS is_visible,
+    DROP COLUMN IF EXISTS synced_at;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE one_hour_price
+    ADD COLUMN derived_from VARCHAR(10);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE one_hour_price
+    DROP COLUMN IF EXISTS derived_from;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE ticker_24h_stats(
+    fromsym VARCHAR(10) NOT NULL,
+    tosym VARCHAR(10) NOT NULL,
+    ts TIMESTAMP NOT NULL,
+    price_change NUMERIC(30, 8) NOT NULL,
+    price_change_percent NUMERIC(12, 4) NOT NULL,
+    high NUMERIC(30, 8) NOT NULL,
+    low NUMERIC(30, 8) NOT NULL,
+    volume NUMERIC NOT NULL,
+    quote_volume NUMERIC NOT NULL,
+    open_time TIMESTAMP NOT NULL,
+    close_time TIMESTAMP NOT NULL,
+    UNIQUE (fromsym, tosym, ts)
+);
+
+CREATE INDEX ticker_24h_stats_ts_idx ON ticker_24h_stats (ts);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS ticker_24h_stats;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE coingecko_coin(
+    ticker VARCHAR(10) NOT NULL PRIMARY KEY,
+    coingecko_id VARCHAR(100) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE coin
+    ALTER COLUMN name TYPE VARCHAR(100),
+    ADD COLUMN price_source VARCHAR(20);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE coin SET name = LEFT(name, 20) WHERE LENGTH(name) > 20;
+ALTER TABLE coin
+    ALTER COLUMN name TYPE VARCHAR(20),
+    DROP COLUMN IF EXISTS price_source;
+DROP TABLE IF EXISTS coingecko_coin;
+-- +goose StatementEnd
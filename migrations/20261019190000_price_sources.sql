@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE one_hour_price
+    ADD COLUMN sources TEXT[];
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE one_hour_price
+    DROP COLUMN IF EXISTS sources;
+-- +goose StatementEnd
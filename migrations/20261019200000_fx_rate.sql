@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE fx_rate(
+    currency VARCHAR(10) NOT NULL,
+    date DATE NOT NULL,
+    rate NUMERIC(30, 10) NOT NULL,
+    source VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (currency, date)
+);
+
+ALTER TABLE tg_chat
+    ADD COLUMN currency VARCHAR(10);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE tg_chat
+    DROP COLUMN IF EXISTS currency;
+
+DROP TABLE IF EXISTS fx_rate;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE stock_session(
+    session_date DATE NOT NULL PRIMARY KEY,
+    tickers TEXT[] NOT NULL,
+    bars INT NOT NULL,
+    fetched_at TIMESTAMP NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS stock_session;
+-- +goose StatementEnd
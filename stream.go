@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/shopspring/decimal"
+	"github.com/zheka156/market_data/internal/config"
+	"github.com/zheka156/market_data/internal/integration/binance"
+	"github.com/zheka156/market_data/internal/job"
+	"github.com/zheka156/market_data/internal/postgres"
+	"github.com/zheka156/market_data/internal/pricefeed"
+	"go.uber.org/zap"
+)
+
+// startBinanceStream feeds live USDT prices of all tracked coins into the feed until ctx is done.
+// Coins quoted in another asset are converted with the live quote/USDT rate.
+func startBinanceStream(ctx context.Context, logger *zap.Logger, conf *config.Config, rep postgres.Repository, feed *pricefeed.Feed) {
+	var mu sync.Mutex
+	// coin pairs by binance symbol, quote assets by the symbol of their USDT rate
+	coins := make(map[string]*postgres.CoinPair)
+	quotes := make(map[string]string)
+	rates := make(map[string]decimal.Decimal)
+
+	symbols := func() ([]string, error) {
+		pairs, err := rep.GetCoinPairs()
+		if err != nil {
+			return nil, err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		coins = make(map[string]*postgres.CoinPair, len(pairs))
+		quotes = make(map[string]string)
+		var symbols []string
+		for _, pair := range pairs {
+			// coins pinned to another price source are not traded on binance
+			if pair.PriceSource != "" {
+				continue
+			}
+			symbol := binance.Symbol(pair.Ticker, pair.QuoteAsset)
+			coins[symbol] = pair
+			symbols = append(symbols, symbol)
+		}
+		for _, pair := range pairs {
+			if pair.PriceSource != "" || pair.QuoteAsset == job.USDT {
+				continue
+			}
+			symbol := binance.Symbol(pair.QuoteAsset, job.USDT)
+			if _, ok := quotes[symbol]; ok {
+				continue
+			}
+			quotes[symbol] = pair.QuoteAsset
+			if _, ok := coins[symbol]; !ok {
+				symbols = append(symbols, symbol)
+			}
+		}
+		return symbols, nil
+	}
+
+	stream := binance.NewStream(logger, conf.Binance.Stream, symbols, func(update binance.StreamUpdate) {
+		mu.Lock()
+		defer mu.Unlock()
+		if quote, ok := quotes[update.Symbol]; ok {
+			rates[quote] = update.Price
+		}
+		pair, ok := coins[update.Symbol]
+		if !ok {
+			return
+		}
+		price := update.Price
+		if pair.QuoteAsset != job.USDT {
+			rate, ok := rates[pair.QuoteAsset]
+			if !ok {
+				return
+			}
+			price = price.Mul(rate).Truncate(8)
+		}
+		feed.Publish(pricefeed.Update{
+			Symbol: pair.Ticker,
+			Quote:  job.USDT,
+			Price:  price,
+			Time:   update.EventTime,
+			Source: "binance",
+		})
+	})
+	stream.Run(ctx)
+}